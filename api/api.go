@@ -2,23 +2,41 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
 	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/mmycroft/boot-dev-chirpy/auth"
 	"github.com/mmycroft/boot-dev-chirpy/database"
+	"github.com/mmycroft/boot-dev-chirpy/moderation"
+	"github.com/mmycroft/boot-dev-chirpy/storage"
 )
 
+// _UNIQUE_VIOLATION is the postgres error code for a unique constraint violation
+const _UNIQUE_VIOLATION = "23505"
+
 type APIConfig struct {
-	FileServerHits atomic.Int32
-	DBQueries      *database.Queries
-	Templates      *template.Template
-	Platform       string
-	Secret         string
+	FileServerHits       atomic.Int32
+	DB                   *sql.DB
+	DBQueries            *database.Queries
+	Templates            *template.Template
+	Platform             string
+	Keys                 *auth.Keyring
+	AccessTokenLifetime  time.Duration
+	RefreshTokenLifetime time.Duration
+	ChirpEditWindow      time.Duration
+	TrendingWindow       time.Duration
+	TrendingHalfLife     time.Duration
+	Moderator            *moderation.Pipeline
+	Storage              storage.Storage
 }
 
 func (cfg *APIConfig) MiddlewareMetricsInc(next http.Handler) http.Handler {
@@ -55,15 +73,39 @@ func (cfg *APIConfig) HandlerResetNumRequests(wr http.ResponseWriter, req *http.
 	if cfg.Platform != "dev" {
 		log.Printf("access denied, platform is not dev")
 		respondWithError(wr, fmt.Errorf("platform is not dev"), http.StatusForbidden)
+		return
+	}
+	// the media rows go with their users, but the stored files must be
+	// deleted by hand
+	dbMedia, err := cfg.DBQueries.GetAllMedia(req.Context())
+	if err != nil {
+		log.Printf("error retrieving media from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
 	}
+
 	if err := cfg.DBQueries.DeleteUsers(req.Context()); err != nil {
 		log.Printf("error deleting users from database: %v", err)
 		respondWithError(wr, err, http.StatusInternalServerError)
+		return
 	}
+
+	cfg.deleteStoredMedia(req.Context(), dbMedia)
 	cfg.FileServerHits.Store(0)
 	cfg.HandlerNumRequests(wr, req)
 }
 
+// HandlerReloadModeration POST /admin/moderation/reload
+func (cfg *APIConfig) HandlerReloadModeration(wr http.ResponseWriter, req *http.Request) {
+	if err := cfg.Moderator.Reload(); err != nil {
+		log.Printf("error reloading moderation rules: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
 // HandlerReadiness GET /api/healthz
 func (cfg *APIConfig) HandlerReadiness(wr http.ResponseWriter, req *http.Request) {
 	wr.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -74,27 +116,141 @@ func (cfg *APIConfig) HandlerReadiness(wr http.ResponseWriter, req *http.Request
 	}
 }
 
+// HandlerJWKS GET /.well-known/jwks.json
+//
+// Lists the public keys access tokens can be signed with, so other services
+// can check them. A retired key is dropped once its tokens have expired.
+func (cfg *APIConfig) HandlerJWKS(wr http.ResponseWriter, req *http.Request) {
+	wr.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(wr, cfg.Keys.JWKS(), http.StatusOK)
+}
+
 // HandlerRefresh POST /api/refresh
+//
+// Rotates the refresh token: the one presented is revoked and a new one in
+// the same family comes back with the access token. A revoked token being
+// presented again means it has leaked, so its whole family is revoked and
+// whoever holds the latest token has to log in again.
 func (cfg *APIConfig) HandlerRefresh(wr http.ResponseWriter, req *http.Request) {
 	tokenString, err := auth.GetBearerToken(req.Header)
 	if err != nil {
 		log.Printf("error pulling refresh token from authorization header: %v", err)
 		respondWithError(wr, err, http.StatusUnauthorized)
+		return
 	}
 
-	dbRefreshToken, err := cfg.DBQueries.GetRefreshToken(req.Context(), tokenString)
+	tx, err := cfg.DB.BeginTx(req.Context(), nil)
+	if err != nil {
+		log.Printf("error starting transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.DBQueries.WithTx(tx)
+
+	lookupPrefix, tokenHash := auth.HashRefreshToken(tokenString)
+
+	tokenParams := database.GetRefreshTokenForUpdateParams{
+		LookupPrefix: lookupPrefix,
+		TokenHash:    tokenHash,
+	}
+
+	// the lock makes concurrent refreshes with one token take turns, so only
+	// the first rotates it
+	dbRefreshToken, err := qtx.GetRefreshTokenForUpdate(req.Context(), tokenParams)
 	if err != nil {
 		log.Printf("error getting refresh token from database: %v", err)
+		respondWithError(wr, fmt.Errorf("invalid refresh token"), http.StatusUnauthorized)
+		return
+	}
+
+	if dbRefreshToken.RevokedAt.Valid {
+		if err := qtx.RevokeRefreshTokenFamily(req.Context(), dbRefreshToken.FamilyID); err != nil {
+			log.Printf("error revoking refresh token family: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Printf("error committing transaction: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("revoked refresh token presented again, revoked family %s of user %s\n", dbRefreshToken.FamilyID, dbRefreshToken.UserID)
+		respondWithError(wr, fmt.Errorf("refresh token has been revoked"), http.StatusUnauthorized)
+		return
+	}
+
+	if !time.Now().Before(dbRefreshToken.ExpiresAt) {
+		err := fmt.Errorf("refresh token has expired")
+		log.Println(err)
 		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	revokeParams := database.RevokeRefreshTokenParams{
+		LookupPrefix: lookupPrefix,
+		TokenHash:    tokenHash,
 	}
 
-	accessToken, err := auth.MakeJWT(dbRefreshToken.UserID, cfg.Secret)
+	if _, err := qtx.RevokeRefreshToken(req.Context(), revokeParams); err != nil {
+		log.Printf("error revoking refresh token: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	refreshTokenString, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("error making Refresh Token %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	newLookupPrefix, newTokenHash := auth.HashRefreshToken(refreshTokenString)
+
+	refreshTokenParams := database.CreateRefreshTokenParams{
+		LookupPrefix: newLookupPrefix,
+		TokenHash:    newTokenHash,
+		UserID:       dbRefreshToken.UserID,
+		FamilyID:     dbRefreshToken.FamilyID,
+		ExpiresAt:    time.Now().Add(cfg.RefreshTokenLifetime),
+	}
+
+	if _, err := qtx.CreateRefreshToken(req.Context(), refreshTokenParams); err != nil {
+		log.Printf("error creating refresh token: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := qtx.TouchSession(req.Context(), dbRefreshToken.FamilyID); err != nil {
+		log.Printf("error updating session: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	dbUser, err := qtx.GetUserByID(req.Context(), dbRefreshToken.UserID)
+	if err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	accessToken, err := cfg.makeAccessToken(req.Context(), qtx, &dbUser, dbRefreshToken.FamilyID)
 	if err != nil {
 		log.Printf("error making JWT token: %v", err)
 		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("error committing transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
 	}
 
-	apiAccessToken := NewAPIToken(accessToken)
+	apiAccessToken := NewAPIToken(accessToken, refreshTokenString)
 
 	respondWithJSON(wr, apiAccessToken, http.StatusOK)
 }
@@ -105,18 +261,58 @@ func (cfg *APIConfig) HandlerRevoke(wr http.ResponseWriter, req *http.Request) {
 	if err != nil {
 		log.Printf("error pulling refresh token from authorization header: %v", err)
 		respondWithError(wr, err, http.StatusUnauthorized)
+		return
 	}
 
-	revokedAt, err := cfg.DBQueries.RevokeRefreshToken(req.Context(), tokenString)
+	lookupPrefix, tokenHash := auth.HashRefreshToken(tokenString)
+
+	revokeParams := database.RevokeRefreshTokenParams{
+		LookupPrefix: lookupPrefix,
+		TokenHash:    tokenHash,
+	}
+
+	revokedAt, err := cfg.DBQueries.RevokeRefreshToken(req.Context(), revokeParams)
 	if err != nil {
 		log.Printf("error revoking refresh token: %v", err)
-		respondWithError(wr, err, http.StatusUnauthorized)
+		respondWithError(wr, fmt.Errorf("invalid refresh token"), http.StatusUnauthorized)
+		return
 	}
-	log.Printf("token %s revoked at %v", tokenString, revokedAt)
+	// only the prefix, the token itself must never reach the logs
+	log.Printf("token %s... revoked at %v", lookupPrefix, revokedAt.Time)
 
 	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
 }
 
+// authenticate validates the bearer access token on the request and returns its user id
+func (cfg *APIConfig) authenticate(req *http.Request) (uuid.UUID, error) {
+	userID, _, err := cfg.authenticateSession(req)
+	return userID, err
+}
+
+// optionalAuthenticate is authenticate for endpoints that also serve anonymous
+// callers: it returns uuid.Nil when no Authorization header is sent
+func (cfg *APIConfig) optionalAuthenticate(req *http.Request) (uuid.UUID, error) {
+	if req.Header.Get("Authorization") == "" {
+		return uuid.Nil, nil
+	}
+
+	return cfg.authenticate(req)
+}
+
+// checkNotSuspended returns an error when userID has been suspended by a moderator
+func (cfg *APIConfig) checkNotSuspended(ctx context.Context, userID uuid.UUID) error {
+	dbUser, err := cfg.DBQueries.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error getting user from database: %w", err)
+	}
+
+	if dbUser.SuspendedAt.Valid {
+		return fmt.Errorf("account has been suspended")
+	}
+
+	return nil
+}
+
 func respondWithError(wr http.ResponseWriter, err error, code int) {
 	log.Printf("%d error: %v\n", code, err)
 
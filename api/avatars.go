@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+	"github.com/mmycroft/boot-dev-chirpy/media"
+)
+
+// avatars come in three square sizes, in pixels
+const (
+	_AVATAR_SMALL  = 48
+	_AVATAR_MEDIUM = 128
+	_AVATAR_LARGE  = 400
+)
+
+var avatarSizes = []int{_AVATAR_SMALL, _AVATAR_MEDIUM, _AVATAR_LARGE}
+
+// avatarKey is where the size variant of the avatar stored under prefix
+// lives
+func avatarKey(prefix string, size int) string {
+	return fmt.Sprintf("%s/%d.png", prefix, size)
+}
+
+// identiconURL is where the generated avatar for a user without one is
+// served
+func identiconURL(userID uuid.UUID, size int) string {
+	return fmt.Sprintf("/api/users/%s/identicon?size=%d", userID, size)
+}
+
+// HandlerSetAvatar PUT /api/users/me/avatar
+//
+// Takes a multipart form with the image in "file". The image is cropped to
+// a centered square and stored in every avatar size under a prefix named by
+// its hash, so an unchanged image maps to the same files. Since two users
+// can share those files they are never deleted.
+func (cfg *APIConfig) HandlerSetAvatar(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	data, err := readUpload(wr, req)
+	if err != nil {
+		log.Printf("error reading upload: %v\n", err)
+		code := http.StatusBadRequest
+		if errors.Is(err, errUploadTooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		respondWithError(wr, err, code)
+		return
+	}
+
+	img, err := media.Process(data)
+	if err != nil {
+		log.Printf("error processing avatar: %v\n", err)
+		code := http.StatusBadRequest
+		if errors.Is(err, media.ErrUnsupportedType) {
+			code = http.StatusUnsupportedMediaType
+		}
+		respondWithError(wr, err, code)
+		return
+	}
+
+	sum := sha256.Sum256(img.Data)
+	digest := hex.EncodeToString(sum[:])
+	prefix := fmt.Sprintf("avatars/%s/%s", digest[:2], digest)
+
+	square := media.CropSquare(img.Image)
+	for _, size := range avatarSizes {
+		variant, contentType, err := media.Encode(media.Resize(square, size, size), "image/png")
+		if err != nil {
+			log.Printf("error resizing avatar: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+
+		if err := cfg.Storage.Put(req.Context(), avatarKey(prefix, size), bytes.NewReader(variant), contentType); err != nil {
+			log.Printf("error storing avatar: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	avatarParams := database.SetUserAvatarParams{
+		ID:        userID,
+		AvatarKey: prefix,
+	}
+
+	dbUser, err := cfg.DBQueries.SetUserAvatar(req.Context(), avatarParams)
+	if err != nil {
+		log.Printf("error setting user avatar: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIUser(&dbUser, cfg.Storage, "", ""), http.StatusOK)
+}
+
+// HandlerDeleteAvatar DELETE /api/users/me/avatar
+//
+// Removes both an uploaded avatar and a picked avatar url, leaving the
+// identicon.
+func (cfg *APIConfig) HandlerDeleteAvatar(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	avatarParams := database.SetUserAvatarParams{
+		ID:        userID,
+		AvatarKey: "",
+	}
+
+	dbUser, err := cfg.DBQueries.SetUserAvatar(req.Context(), avatarParams)
+	if err != nil {
+		log.Printf("error clearing user avatar: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIUser(&dbUser, cfg.Storage, "", ""), http.StatusOK)
+}
+
+// HandlerGetIdenticon GET /api/users/{userID}/identicon
+//
+// The picture only depends on the user id, so it is drawn on every request
+// and cached hard by clients.
+func (cfg *APIConfig) HandlerGetIdenticon(wr http.ResponseWriter, req *http.Request) {
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	size := _AVATAR_MEDIUM
+	if s := req.URL.Query().Get("size"); s != "" {
+		size, err = strconv.Atoi(s)
+		if err != nil || (size != _AVATAR_SMALL && size != _AVATAR_MEDIUM && size != _AVATAR_LARGE) {
+			err := fmt.Errorf("size must be one of %v", avatarSizes)
+			log.Println(err)
+			respondWithError(wr, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	data, contentType, err := media.Encode(media.Identicon(userID[:], size), "image/png")
+	if err != nil {
+		log.Printf("error drawing identicon: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	wr.Header().Set("Content-Type", contentType)
+	wr.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	wr.WriteHeader(http.StatusOK)
+	if _, err := wr.Write(data); err != nil {
+		log.Printf("error writing identicon: %v\n", err)
+	}
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+// checkNotBlocked returns an error when blockerID has blocked userID, for
+// the interactions a block forbids
+func (cfg *APIConfig) checkNotBlocked(ctx context.Context, blockerID, userID uuid.UUID) error {
+	blockParams := database.IsBlockedParams{
+		BlockerID: blockerID,
+		BlockedID: userID,
+	}
+
+	blocked, err := cfg.DBQueries.IsBlocked(ctx, blockParams)
+	if err != nil {
+		return fmt.Errorf("error checking block: %w", err)
+	}
+
+	if blocked {
+		return fmt.Errorf("you have been blocked by this user")
+	}
+
+	return nil
+}
+
+// HandlerBlockUser POST /api/users/{userID}/block
+//
+// Blocking also removes any follow between the two users.
+func (cfg *APIConfig) HandlerBlockUser(wr http.ResponseWriter, req *http.Request) {
+	blockerID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	blockedID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if blockerID == blockedID {
+		err := fmt.Errorf("users cannot block themselves")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := cfg.DBQueries.GetUserByID(req.Context(), blockedID); err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(req.Context(), nil)
+	if err != nil {
+		log.Printf("error starting transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.DBQueries.WithTx(tx)
+
+	blockParams := database.CreateBlockParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	}
+
+	if err := qtx.CreateBlock(req.Context(), blockParams); err != nil {
+		log.Printf("error creating block: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	for _, followParams := range []database.DeleteFollowParams{
+		{FollowerID: blockedID, FolloweeID: blockerID},
+		{FollowerID: blockerID, FolloweeID: blockedID},
+	} {
+		if err := qtx.DeleteFollow(req.Context(), followParams); err != nil {
+			log.Printf("error deleting follow: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("error committing transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerUnblockUser DELETE /api/users/{userID}/block
+func (cfg *APIConfig) HandlerUnblockUser(wr http.ResponseWriter, req *http.Request) {
+	blockerID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	blockedID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	blockParams := database.DeleteBlockParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	}
+
+	if err := cfg.DBQueries.DeleteBlock(req.Context(), blockParams); err != nil {
+		log.Printf("error deleting block: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerMuteUser POST /api/users/{userID}/mute
+func (cfg *APIConfig) HandlerMuteUser(wr http.ResponseWriter, req *http.Request) {
+	muterID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	mutedID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if muterID == mutedID {
+		err := fmt.Errorf("users cannot mute themselves")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := cfg.DBQueries.GetUserByID(req.Context(), mutedID); err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	muteParams := database.CreateMuteParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	}
+
+	if err := cfg.DBQueries.CreateMute(req.Context(), muteParams); err != nil {
+		log.Printf("error creating mute: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerUnmuteUser DELETE /api/users/{userID}/mute
+func (cfg *APIConfig) HandlerUnmuteUser(wr http.ResponseWriter, req *http.Request) {
+	muterID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	mutedID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	muteParams := database.DeleteMuteParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	}
+
+	if err := cfg.DBQueries.DeleteMute(req.Context(), muteParams); err != nil {
+		log.Printf("error deleting mute: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerGetMyBlocks GET /api/users/me/blocks
+func (cfg *APIConfig) HandlerGetMyBlocks(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(req.URL.Query())
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	blockedParams := database.GetBlockedParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	rows, err := cfg.DBQueries.GetBlocked(req.Context(), blockedParams)
+	if err != nil {
+		log.Printf("error retrieving blocks from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	blocked := make([]relatedUser, len(rows))
+	for i, row := range rows {
+		blocked[i] = relatedUser{
+			User:  row.User,
+			Since: row.BlockedAt,
+		}
+	}
+
+	respondWithJSON(wr, newRelatedUserPage(blocked, page.Limit, cfg.Storage), http.StatusOK)
+}
+
+// HandlerGetMyMutes GET /api/users/me/mutes
+func (cfg *APIConfig) HandlerGetMyMutes(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(req.URL.Query())
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	mutedParams := database.GetMutedParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	rows, err := cfg.DBQueries.GetMuted(req.Context(), mutedParams)
+	if err != nil {
+		log.Printf("error retrieving mutes from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	muted := make([]relatedUser, len(rows))
+	for i, row := range rows {
+		muted[i] = relatedUser{
+			User:  row.User,
+			Since: row.MutedAt,
+		}
+	}
+
+	respondWithJSON(wr, newRelatedUserPage(muted, page.Limit, cfg.Storage), http.StatusOK)
+}
@@ -0,0 +1,372 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+const _MAX_COLLECTION_NAME_LENGTH = 50
+
+// parseCollectionName trims a bookmark collection name and checks its length
+func parseCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > _MAX_COLLECTION_NAME_LENGTH {
+		return "", fmt.Errorf("collection name must be 1-%d characters", _MAX_COLLECTION_NAME_LENGTH)
+	}
+	return name, nil
+}
+
+// HandlerBookmarkChirp PUT /api/chirps/{chirpID}/bookmark
+//
+// Bookmarking an already bookmarked chirp moves it to the given collection,
+// or out of any collection when collection_id is omitted.
+func (cfg *APIConfig) HandlerBookmarkChirp(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	reqBody := struct {
+		CollectionID uuid.NullUUID `json:"collection_id"`
+	}{}
+
+	// the body is optional
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil && !errors.Is(err, io.EOF) {
+		log.Printf("error decoding request body: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	dbChirp, err := cfg.DBQueries.GetChirp(req.Context(), chirpID)
+	if err != nil || dbChirp.HiddenAt.Valid {
+		err := fmt.Errorf("chirp not found")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if reqBody.CollectionID.Valid {
+		collectionParams := database.GetBookmarkCollectionParams{
+			ID:     reqBody.CollectionID.UUID,
+			UserID: userID,
+		}
+
+		if _, err := cfg.DBQueries.GetBookmarkCollection(req.Context(), collectionParams); err != nil {
+			log.Printf("error getting bookmark collection from database: %v\n", err)
+			respondWithError(wr, fmt.Errorf("collection not found: %w", err), http.StatusNotFound)
+			return
+		}
+	}
+
+	bookmarkParams := database.CreateBookmarkParams{
+		UserID:       userID,
+		ChirpID:      chirpID,
+		CollectionID: reqBody.CollectionID,
+	}
+
+	if err := cfg.DBQueries.CreateBookmark(req.Context(), bookmarkParams); err != nil {
+		log.Printf("error creating bookmark: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerUnbookmarkChirp DELETE /api/chirps/{chirpID}/bookmark
+func (cfg *APIConfig) HandlerUnbookmarkChirp(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	bookmarkParams := database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	}
+
+	if err := cfg.DBQueries.DeleteBookmark(req.Context(), bookmarkParams); err != nil {
+		log.Printf("error deleting bookmark: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerGetMyBookmarks GET /api/users/me/bookmarks
+func (cfg *APIConfig) HandlerGetMyBookmarks(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	query := req.URL.Query()
+
+	page, err := parsePageParams(query)
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	collectionID, err := parseOptionalUUID(query, "collection_id")
+	if err != nil {
+		log.Printf("error parsing collection_id: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	bookmarksParams := database.GetBookmarksParams{
+		UserID:          userID,
+		CollectionID:    collectionID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	rows, err := cfg.DBQueries.GetBookmarks(req.Context(), bookmarksParams)
+	if err != nil {
+		log.Printf("error retrieving bookmarks from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	rows, cursor := nextCursor(rows, page.Limit, func(row database.GetBookmarksRow) pageCursor {
+		return pageCursor{CreatedAt: row.BookmarkedAt, ID: row.Chirp.ID}
+	})
+
+	// chirps hidden by a moderator or by authors the caller has since blocked
+	// or muted become tombstones
+	hidden := make([]bool, len(rows))
+	apiChirps := []APIChirp{}
+	for i, row := range rows {
+		hidden[i] = row.Chirp.HiddenAt.Valid || row.MutedAuthor
+		if !hidden[i] {
+			apiChirps = append(apiChirps, NewAPIChirp(&row.Chirp))
+		}
+	}
+
+	if err := cfg.loadChirpDetails(req.Context(), userID, apiChirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiBookmarkPage := APIBookmarkPage{
+		Bookmarks:  make([]APIBookmark, len(rows)),
+		NextCursor: cursor,
+	}
+
+	visible := 0
+	for i, row := range rows {
+		apiBookmarkPage.Bookmarks[i] = APIBookmark{
+			ChirpID:      row.Chirp.ID,
+			CollectionID: row.CollectionID,
+			CreatedAt:    row.BookmarkedAt,
+			Hidden:       hidden[i],
+		}
+		if !hidden[i] {
+			apiBookmarkPage.Bookmarks[i].Chirp = &apiChirps[visible]
+			visible++
+		}
+	}
+
+	respondWithJSON(wr, apiBookmarkPage, http.StatusOK)
+}
+
+// HandlerCreateBookmarkCollection POST /api/users/me/collections
+func (cfg *APIConfig) HandlerCreateBookmarkCollection(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	reqBody := struct {
+		Name string `json:"name"`
+	}{}
+
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		log.Printf("error decoding request body: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	name, err := parseCollectionName(reqBody.Name)
+	if err != nil {
+		log.Printf("error parsing collection name: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	collectionParams := database.CreateBookmarkCollectionParams{
+		UserID: userID,
+		Name:   name,
+	}
+
+	dbCollection, err := cfg.DBQueries.CreateBookmarkCollection(req.Context(), collectionParams)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == _UNIQUE_VIOLATION {
+			err = fmt.Errorf("you already have a collection named %q", name)
+			log.Println(err)
+			respondWithError(wr, err, http.StatusConflict)
+			return
+		}
+		log.Printf("error creating bookmark collection: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIBookmarkCollection(&dbCollection, 0), http.StatusCreated)
+}
+
+// HandlerGetBookmarkCollections GET /api/users/me/collections
+func (cfg *APIConfig) HandlerGetBookmarkCollections(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	rows, err := cfg.DBQueries.GetBookmarkCollections(req.Context(), userID)
+	if err != nil {
+		log.Printf("error retrieving bookmark collections from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiCollections := make([]APIBookmarkCollection, len(rows))
+	for i, row := range rows {
+		apiCollections[i] = NewAPIBookmarkCollection(&row.BookmarkCollection, row.BookmarkCount)
+	}
+
+	respondWithJSON(wr, apiCollections, http.StatusOK)
+}
+
+// HandlerRenameBookmarkCollection PATCH /api/users/me/collections/{collectionID}
+func (cfg *APIConfig) HandlerRenameBookmarkCollection(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	collectionID, err := uuid.Parse(req.PathValue("collectionID"))
+	if err != nil {
+		log.Printf("error parsing path {collectionID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	reqBody := struct {
+		Name string `json:"name"`
+	}{}
+
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		log.Printf("error decoding request body: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	name, err := parseCollectionName(reqBody.Name)
+	if err != nil {
+		log.Printf("error parsing collection name: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	renameParams := database.RenameBookmarkCollectionParams{
+		ID:     collectionID,
+		UserID: userID,
+		Name:   name,
+	}
+
+	dbCollection, err := cfg.DBQueries.RenameBookmarkCollection(req.Context(), renameParams)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == _UNIQUE_VIOLATION {
+			err = fmt.Errorf("you already have a collection named %q", name)
+			log.Println(err)
+			respondWithError(wr, err, http.StatusConflict)
+			return
+		}
+		log.Printf("error renaming bookmark collection: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	bookmarkCount, err := cfg.DBQueries.CountCollectionBookmarks(req.Context(), uuid.NullUUID{UUID: dbCollection.ID, Valid: true})
+	if err != nil {
+		log.Printf("error counting collection bookmarks: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIBookmarkCollection(&dbCollection, bookmarkCount), http.StatusOK)
+}
+
+// HandlerDeleteBookmarkCollection DELETE /api/users/me/collections/{collectionID}
+//
+// The collection's bookmarks are kept, outside of any collection.
+func (cfg *APIConfig) HandlerDeleteBookmarkCollection(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	collectionID, err := uuid.Parse(req.PathValue("collectionID"))
+	if err != nil {
+		log.Printf("error parsing path {collectionID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	collectionParams := database.DeleteBookmarkCollectionParams{
+		ID:     collectionID,
+		UserID: userID,
+	}
+
+	if err := cfg.DBQueries.DeleteBookmarkCollection(req.Context(), collectionParams); err != nil {
+		log.Printf("error deleting bookmark collection: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
@@ -1,28 +1,204 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 
-	"github.com/mmycroft/boot-dev-chirpy/auth"
 	"github.com/mmycroft/boot-dev-chirpy/database"
+	"github.com/mmycroft/boot-dev-chirpy/moderation"
 )
 
+const _MAX_CHIRP_LENGTH = 140
+
+// chirpRequest is what a new chirp is made from, whether it is posted
+// directly or published from a draft
+type chirpRequest struct {
+	Body      string        `json:"body"`
+	InReplyTo uuid.NullUUID `json:"in_reply_to"`
+	RechirpOf uuid.NullUUID `json:"rechirp_of"`
+	QuoteOf   uuid.NullUUID `json:"quote_of"`
+	MediaIDs  []uuid.UUID   `json:"media_ids"`
+	Poll      *pollRequest  `json:"poll"`
+}
+
+// checkChirpRequest checks the rules between the parts of a new chirp that
+// need no lookups
+func checkChirpRequest(chirpReq *chirpRequest) error {
+	if chirpReq.RechirpOf.Valid && chirpReq.QuoteOf.Valid {
+		return fmt.Errorf("a chirp cannot be both a rechirp and a quote")
+	}
+
+	if chirpReq.RechirpOf.Valid && chirpReq.Body != "" {
+		return fmt.Errorf("rechirps cannot have a body, use quote_of instead")
+	}
+
+	if chirpReq.QuoteOf.Valid && chirpReq.Body == "" {
+		return fmt.Errorf("quote chirps must have a body, use rechirp_of instead")
+	}
+
+	if chirpReq.RechirpOf.Valid && len(chirpReq.MediaIDs) > 0 {
+		return fmt.Errorf("rechirps cannot have media, use quote_of instead")
+	}
+
+	if chirpReq.RechirpOf.Valid && chirpReq.Poll != nil {
+		return fmt.Errorf("rechirps cannot have a poll, use quote_of instead")
+	}
+
+	return checkMediaIDs(chirpReq.MediaIDs)
+}
+
+// createChirp validates chirpReq for userID and creates the chirp with its
+// media, poll, tags, mentions and flags using q, which should be in a
+// transaction. On failure it also returns the status code to answer with.
+func (cfg *APIConfig) createChirp(ctx context.Context, q *database.Queries, userID uuid.UUID, chirpReq *chirpRequest) (database.Chirp, int, error) {
+	if err := cfg.checkNotSuspended(ctx, userID); err != nil {
+		return database.Chirp{}, http.StatusForbidden, err
+	}
+
+	moderated, err := cfg.cleanChirpBody(chirpReq.Body)
+	if err != nil {
+		return database.Chirp{}, http.StatusBadRequest, err
+	}
+
+	chirpParams := database.CreateChirpParams{
+		Body:   moderated.Text,
+		UserID: userID,
+	}
+
+	if chirpReq.InReplyTo.Valid {
+		parentChirp, err := q.GetChirp(ctx, chirpReq.InReplyTo.UUID)
+		if err != nil {
+			return database.Chirp{}, http.StatusNotFound, fmt.Errorf("chirp being replied to not found: %w", err)
+		}
+
+		if err := cfg.checkNotBlocked(ctx, parentChirp.UserID, userID); err != nil {
+			return database.Chirp{}, http.StatusForbidden, err
+		}
+
+		chirpParams.InReplyToID = uuid.NullUUID{UUID: parentChirp.ID, Valid: true}
+		chirpParams.ThreadID = uuid.NullUUID{UUID: parentChirp.ID, Valid: true}
+		if parentChirp.ThreadID.Valid {
+			chirpParams.ThreadID = parentChirp.ThreadID
+		}
+	}
+
+	if err := checkChirpRequest(chirpReq); err != nil {
+		return database.Chirp{}, http.StatusBadRequest, err
+	}
+
+	var pollOptions []moderation.Result
+	if chirpReq.Poll != nil {
+		options, err := validatePoll(chirpReq.Poll, time.Now())
+		if err != nil {
+			return database.Chirp{}, http.StatusBadRequest, err
+		}
+
+		for _, option := range options {
+			moderatedOption, err := cfg.cleanChirpBody(option)
+			if err != nil {
+				return database.Chirp{}, http.StatusBadRequest, err
+			}
+			pollOptions = append(pollOptions, moderatedOption)
+		}
+	}
+
+	if referencedID := chirpReq.RechirpOf.UUID; chirpReq.RechirpOf.Valid || chirpReq.QuoteOf.Valid {
+		if chirpReq.QuoteOf.Valid {
+			referencedID = chirpReq.QuoteOf.UUID
+		}
+
+		referencedChirp, err := q.GetChirp(ctx, referencedID)
+		if err != nil {
+			return database.Chirp{}, http.StatusNotFound, fmt.Errorf("chirp being rechirped not found: %w", err)
+		}
+
+		if err := cfg.checkNotBlocked(ctx, referencedChirp.UserID, userID); err != nil {
+			return database.Chirp{}, http.StatusForbidden, err
+		}
+
+		// sharing a plain rechirp shares the chirp it points at, so its
+		// author must not have blocked the caller either
+		if referencedChirp.IsRechirp && referencedChirp.QuotedChirpID.Valid {
+			originalChirp, err := q.GetChirp(ctx, referencedChirp.QuotedChirpID.UUID)
+			if err != nil {
+				return database.Chirp{}, http.StatusNotFound, fmt.Errorf("chirp being rechirped not found: %w", err)
+			}
+
+			if err := cfg.checkNotBlocked(ctx, originalChirp.UserID, userID); err != nil {
+				return database.Chirp{}, http.StatusForbidden, err
+			}
+
+			referencedID = originalChirp.ID
+		}
+
+		chirpParams.QuotedChirpID = uuid.NullUUID{UUID: referencedID, Valid: true}
+		chirpParams.IsRechirp = chirpReq.RechirpOf.Valid
+	}
+
+	dbChirp, err := q.CreateChirp(ctx, chirpParams)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == _UNIQUE_VIOLATION && chirpParams.IsRechirp {
+			return database.Chirp{}, http.StatusConflict, fmt.Errorf("chirp has already been rechirped")
+		}
+		return database.Chirp{}, http.StatusInternalServerError, fmt.Errorf("error creating database chirp: %w", err)
+	}
+
+	if err := attachChirpMedia(ctx, q, &dbChirp, chirpReq.MediaIDs); err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, errMediaUnavailable) {
+			code = http.StatusBadRequest
+		}
+		return database.Chirp{}, code, err
+	}
+
+	if chirpReq.Poll != nil {
+		options := make([]string, len(pollOptions))
+		for i, moderatedOption := range pollOptions {
+			options[i] = moderatedOption.Text
+
+			if err := flagChirp(ctx, q, &dbChirp, &moderatedOption); err != nil {
+				return database.Chirp{}, http.StatusInternalServerError, fmt.Errorf("error saving chirp flag: %w", err)
+			}
+		}
+
+		if err := createChirpPoll(ctx, q, &dbChirp, options, chirpReq.Poll.ClosesAt); err != nil {
+			return database.Chirp{}, http.StatusInternalServerError, fmt.Errorf("error creating chirp poll: %w", err)
+		}
+	}
+
+	if err := syncChirpTags(ctx, q, &dbChirp); err != nil {
+		return database.Chirp{}, http.StatusInternalServerError, fmt.Errorf("error saving chirp tags: %w", err)
+	}
+
+	if err := syncChirpMentions(ctx, q, &dbChirp); err != nil {
+		return database.Chirp{}, http.StatusInternalServerError, fmt.Errorf("error saving chirp mentions: %w", err)
+	}
+
+	if err := flagChirp(ctx, q, &dbChirp, &moderated); err != nil {
+		return database.Chirp{}, http.StatusInternalServerError, fmt.Errorf("error saving chirp flag: %w", err)
+	}
+
+	return dbChirp, http.StatusCreated, nil
+}
+
 // HandlerCreateChirp POST /api/chirps
 func (cfg *APIConfig) HandlerCreateChirp(wr http.ResponseWriter, req *http.Request) {
-	const _MAX_CHIRP_LENGTH = 140
-
 	reqBody := struct {
-		Body   string    `json:"body"`
+		chirpRequest
 		UserID uuid.UUID `json:"user_id"`
 	}{}
 
-	fmt.Printf("Empty reqBody: %s\n\n", reqBody)
+	fmt.Printf("Empty reqBody: %v\n\n", reqBody)
 
 	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
 		log.Printf("error decoding request body: %v\n", err)
@@ -30,79 +206,407 @@ func (cfg *APIConfig) HandlerCreateChirp(wr http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	fmt.Printf("Filled reqBody: %s\n\n", reqBody)
+	fmt.Printf("Filled reqBody: %v\n\n", reqBody)
 
-	tokenString, err := auth.GetBearerToken(req.Header)
+	userID, err := cfg.authenticate(req)
 	if err != nil {
-		log.Printf("error getting token from header: %v\n", err)
+		log.Printf("error authenticating request: %v\n", err)
 		respondWithError(wr, err, http.StatusUnauthorized)
 		return
 	}
 
-	fmt.Printf("tokenString: %s\n\n", tokenString)
+	tx, err := cfg.DB.BeginTx(req.Context(), nil)
+	if err != nil {
+		log.Printf("error starting transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	dbChirp, code, err := cfg.createChirp(req.Context(), cfg.DBQueries.WithTx(tx), userID, &reqBody.chirpRequest)
+	if err != nil {
+		log.Printf("error creating chirp: %v\n", err)
+		respondWithError(wr, err, code)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("error committing transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiChirps := []APIChirp{NewAPIChirp(&dbChirp)}
+
+	if err := cfg.loadChirpDetails(req.Context(), userID, apiChirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, apiChirps[0], http.StatusCreated)
+}
 
-	userID, err := auth.ValidateJWT(tokenString, cfg.Secret)
+// HandlerUpdateChirp PATCH /api/chirps/{chirpID}
+func (cfg *APIConfig) HandlerUpdateChirp(wr http.ResponseWriter, req *http.Request) {
+	reqUserID, err := cfg.authenticate(req)
 	if err != nil {
-		log.Printf("error validating JWT token: %v\n", err)
+		log.Printf("error authenticating request: %v\n", err)
 		respondWithError(wr, err, http.StatusUnauthorized)
 		return
 	}
 
-	if len(reqBody.Body) > _MAX_CHIRP_LENGTH {
-		log.Printf("chirp must be 140 characters or less\n")
-		respondWithError(wr, fmt.Errorf("chirp is too long"), http.StatusBadRequest)
+	if err := cfg.checkNotSuspended(req.Context(), reqUserID); err != nil {
+		log.Printf("error checking suspension: %v\n", err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	reqBody := struct {
+		Body string `json:"body"`
+	}{}
+
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		log.Printf("error decoding request body: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
 		return
 	}
 
-	badWords := map[string]bool{
-		"kerfuffle": true,
-		"sharbert":  true,
-		"fornax":    true,
+	moderated, err := cfg.cleanChirpBody(reqBody.Body)
+	if err != nil {
+		log.Printf("error cleaning chirp body: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
 	}
 
-	words := strings.Split(reqBody.Body, " ")
-	for i, word := range words {
-		if badWords[strings.ToLower(word)] {
-			words[i] = "****"
+	tx, err := cfg.DB.BeginTx(req.Context(), nil)
+	if err != nil {
+		log.Printf("error starting transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.DBQueries.WithTx(tx)
+
+	dbChirp, err := qtx.GetChirpForUpdate(req.Context(), chirpID)
+	if err != nil {
+		log.Printf("error getting chirp from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if reqUserID != dbChirp.UserID {
+		err := fmt.Errorf("request user id does not match chirp user id")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	if dbChirp.IsRechirp {
+		err := fmt.Errorf("rechirps cannot be edited")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	if time.Since(dbChirp.CreatedAt) > cfg.ChirpEditWindow {
+		err := fmt.Errorf("chirp can only be edited within %v of posting", cfg.ChirpEditWindow)
+		log.Println(err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	if body := moderated.Text; body != dbChirp.Body {
+		revisionParams := database.CreateChirpRevisionParams{
+			ChirpID:    dbChirp.ID,
+			Body:       dbChirp.Body,
+			AuthoredAt: dbChirp.UpdatedAt,
+		}
+
+		if _, err := qtx.CreateChirpRevision(req.Context(), revisionParams); err != nil {
+			log.Printf("error creating chirp revision: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+
+		updateParams := database.UpdateChirpBodyParams{
+			ID:   dbChirp.ID,
+			Body: body,
+		}
+
+		dbChirp, err = qtx.UpdateChirpBody(req.Context(), updateParams)
+		if err != nil {
+			log.Printf("error updating chirp body: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+
+		if err := syncChirpTags(req.Context(), qtx, &dbChirp); err != nil {
+			log.Printf("error saving chirp tags: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+
+		if err := syncChirpMentions(req.Context(), qtx, &dbChirp); err != nil {
+			log.Printf("error saving chirp mentions: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+
+		if err := flagChirp(req.Context(), qtx, &dbChirp, &moderated); err != nil {
+			log.Printf("error saving chirp flag: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
 		}
 	}
 
-	chirpParams := database.CreateChirpParams{
-		Body:   strings.Join(words, " "),
-		UserID: userID,
+	if err := tx.Commit(); err != nil {
+		log.Printf("error committing transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiChirps := []APIChirp{NewAPIChirp(&dbChirp)}
+
+	if err := cfg.loadChirpDetails(req.Context(), reqUserID, apiChirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, apiChirps[0], http.StatusOK)
+}
+
+// checkChirpVisible returns an error, and the status to respond with, when
+// viewerID may not see dbChirp: its author is blocked or muted by the
+// viewer, or it was hidden by a moderator and the viewer is not one
+func (cfg *APIConfig) checkChirpVisible(ctx context.Context, viewerID uuid.UUID, dbChirp *database.Chirp) (int, error) {
+	if viewerID != uuid.Nil {
+		excludedParams := database.IsExcludedByViewerParams{
+			ViewerID: viewerID,
+			UserID:   dbChirp.UserID,
+		}
+
+		excluded, err := cfg.DBQueries.IsExcludedByViewer(ctx, excludedParams)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("error checking blocks and mutes: %w", err)
+		}
+		if excluded {
+			return http.StatusNotFound, fmt.Errorf("chirp author is blocked or muted")
+		}
+	}
+
+	if dbChirp.HiddenAt.Valid {
+		canModerate, err := cfg.hasAnyRole(ctx, viewerID, RoleAdmin, RoleModerator)
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("error checking roles: %w", err)
+		}
+		if !canModerate {
+			return http.StatusNotFound, fmt.Errorf("chirp has been hidden by a moderator")
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// HandlerGetChirpHistory GET /api/chirps/{chirpID}/history
+func (cfg *APIConfig) HandlerGetChirpHistory(wr http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.optionalAuthenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
 	}
 
-	dbChirp, err := cfg.DBQueries.CreateChirp(req.Context(), chirpParams)
+	dbChirp, err := cfg.DBQueries.GetChirp(req.Context(), chirpID)
+	if err != nil {
+		log.Printf("error getting chirp from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if code, err := cfg.checkChirpVisible(req.Context(), viewerID, &dbChirp); err != nil {
+		log.Printf("error checking chirp visibility: %v\n", err)
+		respondWithError(wr, err, code)
+		return
+	}
+
+	dbRevisions, err := cfg.DBQueries.GetChirpRevisions(req.Context(), chirpID)
 	if err != nil {
-		log.Printf("error creating database chirp: %v\n", err)
+		log.Printf("error retrieving chirp revisions from database: %v\n", err)
 		respondWithError(wr, err, http.StatusInternalServerError)
 		return
 	}
 
-	apiChirp := NewAPIChirp(&dbChirp)
+	apiChirpHistory := NewAPIChirpHistory(&dbChirp, dbRevisions)
 
-	respondWithJSON(wr, apiChirp, http.StatusCreated)
+	respondWithJSON(wr, apiChirpHistory, http.StatusOK)
 }
 
 // HandlerGetChirps GET /api/chirps
 func (cfg *APIConfig) HandlerGetChirps(wr http.ResponseWriter, req *http.Request) {
-	dbChirps, err := cfg.DBQueries.GetChirps(req.Context())
+	viewerID, err := cfg.optionalAuthenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	query := req.URL.Query()
+
+	page, err := parsePageParams(query)
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	authorID, err := parseOptionalUUID(query, "author_id")
+	if err != nil {
+		log.Printf("error parsing author_id: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	since, err := parseOptionalTime(query, "since")
+	if err != nil {
+		log.Printf("error parsing since: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	until, err := parseOptionalTime(query, "until")
+	if err != nil {
+		log.Printf("error parsing until: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	includeHidden, err := cfg.hasAnyRole(req.Context(), viewerID, RoleAdmin, RoleModerator)
+	if err != nil {
+		log.Printf("error checking roles: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	pinnedFirst := false
+	if s := query.Get("pinned_first"); s != "" {
+		pinnedFirst, err = strconv.ParseBool(s)
+		if err != nil {
+			log.Printf("error parsing pinned_first: %v\n", err)
+			respondWithError(wr, fmt.Errorf("invalid pinned_first: %w", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if pinnedFirst && !authorID.Valid {
+		err := fmt.Errorf("pinned_first needs an author_id")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	// the pinned chirp leads the first page and is left out of the pages
+	// themselves, so it is never listed twice
+	pinnedID := uuid.NullUUID{}
+	if pinnedFirst {
+		pinnedID, err = cfg.pinnedChirpID(req.Context(), authorID.UUID)
+		if err != nil {
+			log.Printf("error getting pinned chirp: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var dbChirps []database.Chirp
+
+	switch sort := query.Get("sort"); sort {
+	case "", "asc":
+		dbChirps, err = cfg.DBQueries.GetChirpsPageAsc(req.Context(), database.GetChirpsPageAscParams{
+			AuthorID:        authorID,
+			ViewerID:        viewerID,
+			Since:           since,
+			Until:           until,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID:        page.CursorID,
+			ExcludeID:       pinnedID,
+			IncludeHidden:   includeHidden,
+			Limit:           page.Limit + 1,
+		})
+	case "desc":
+		dbChirps, err = cfg.DBQueries.GetChirpsPageDesc(req.Context(), database.GetChirpsPageDescParams{
+			AuthorID:        authorID,
+			ViewerID:        viewerID,
+			Since:           since,
+			Until:           until,
+			CursorCreatedAt: page.CursorCreatedAt,
+			CursorID:        page.CursorID,
+			ExcludeID:       pinnedID,
+			IncludeHidden:   includeHidden,
+			Limit:           page.Limit + 1,
+		})
+	default:
+		err := fmt.Errorf("sort must be asc or desc, got %q", sort)
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		log.Printf("error retrieving chirps from database: %v\n", err)
-		respondWithError(wr, err, http.StatusNotFound)
+		respondWithError(wr, err, http.StatusInternalServerError)
 		return
 	}
 
-	apiChirps := make([]APIChirp, len(dbChirps))
-	for i, dbChirp := range dbChirps {
-		apiChirps[i] = NewAPIChirp(&dbChirp)
+	apiChirpPage := NewAPIChirpPage(dbChirps, page.Limit)
+
+	if pinnedID.Valid && !page.CursorID.Valid {
+		dbPinned, err := cfg.pinnedChirp(req.Context(), pinnedID.UUID, viewerID, since, until, includeHidden)
+		if err != nil {
+			log.Printf("error getting pinned chirp: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+
+		if dbPinned != nil {
+			apiPinned := NewAPIChirp(dbPinned)
+			apiPinned.Pinned = true
+			apiChirpPage.Chirps = append([]APIChirp{apiPinned}, apiChirpPage.Chirps...)
+		}
+	}
+
+	if err := cfg.loadChirpDetails(req.Context(), viewerID, apiChirpPage.Chirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
 	}
 
-	respondWithJSON(wr, apiChirps, http.StatusOK)
+	respondWithJSON(wr, apiChirpPage, http.StatusOK)
 }
 
 // HandlerGetChirp GET /api/chirps{chirpID}
 func (cfg *APIConfig) HandlerGetChirp(wr http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.optionalAuthenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
 	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
 	if err != nil {
 		log.Printf("error parsing path {chirpID}: %v", err)
@@ -117,23 +621,28 @@ func (cfg *APIConfig) HandlerGetChirp(wr http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	apiChirp := NewAPIChirp(&dbChirp)
+	if code, err := cfg.checkChirpVisible(req.Context(), viewerID, &dbChirp); err != nil {
+		log.Printf("error checking chirp visibility: %v\n", err)
+		respondWithError(wr, err, code)
+		return
+	}
 
-	respondWithJSON(wr, apiChirp, http.StatusOK)
-}
+	apiChirps := []APIChirp{NewAPIChirp(&dbChirp)}
 
-// HandlerDeleteChirp DELETE /api/chirps/{chirpID}
-func (cfg *APIConfig) HandlerDeleteChirp(wr http.ResponseWriter, req *http.Request) {
-	accessToken, err := auth.GetBearerToken(req.Header)
-	if err != nil {
-		log.Printf("error pulling access token from authorization header: %v\n", err)
-		respondWithError(wr, err, http.StatusUnauthorized)
+	if err := cfg.loadChirpDetails(req.Context(), viewerID, apiChirps); err != nil {
+		log.Printf("error loading chirp details: %v", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
 		return
 	}
 
-	reqUserID, err := auth.ValidateJWT(accessToken, cfg.Secret)
+	respondWithJSON(wr, apiChirps[0], http.StatusOK)
+}
+
+// HandlerDeleteChirp DELETE /api/chirps/{chirpID}
+func (cfg *APIConfig) HandlerDeleteChirp(wr http.ResponseWriter, req *http.Request) {
+	reqUserID, err := cfg.authenticate(req)
 	if err != nil {
-		log.Printf("error validating access token: %v\n", err)
+		log.Printf("error authenticating request: %v\n", err)
 		respondWithError(wr, err, http.StatusUnauthorized)
 		return
 	}
@@ -159,11 +668,296 @@ func (cfg *APIConfig) HandlerDeleteChirp(wr http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	dbMedia, err := cfg.DBQueries.GetChirpMedia(req.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		log.Printf("error retrieving chirp media from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
 	if err = cfg.DBQueries.DeleteChirp(req.Context(), chirpID); err != nil {
 		log.Printf("error deleting chirp from database: %v\n", err)
 		respondWithError(wr, err, http.StatusInternalServerError)
 		return
 	}
 
+	cfg.deleteStoredMedia(req.Context(), dbMedia)
+
 	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
 }
+
+// HandlerGetTimeline GET /api/timeline
+func (cfg *APIConfig) HandlerGetTimeline(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(req.URL.Query())
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	timelineParams := database.GetTimelineParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	dbChirps, err := cfg.DBQueries.GetTimeline(req.Context(), timelineParams)
+	if err != nil {
+		log.Printf("error retrieving timeline from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiChirpPage := NewAPIChirpPage(dbChirps, page.Limit)
+
+	if err := cfg.loadChirpDetails(req.Context(), userID, apiChirpPage.Chirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, apiChirpPage, http.StatusOK)
+}
+
+// HandlerGetThread GET /api/chirps/{chirpID}/thread
+func (cfg *APIConfig) HandlerGetThread(wr http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.optionalAuthenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	// a chirp that no longer exists may still be the root of a thread whose
+	// replies survived it, so fall back to treating the id as the root
+	rootID := chirpID
+	dbChirp, err := cfg.DBQueries.GetChirp(req.Context(), chirpID)
+	if err == nil && dbChirp.ThreadID.Valid {
+		rootID = dbChirp.ThreadID.UUID
+	}
+
+	threadParams := database.GetThreadParams{
+		ThreadID: rootID,
+		ViewerID: viewerID,
+	}
+
+	rows, err := cfg.DBQueries.GetThread(req.Context(), threadParams)
+	if err != nil {
+		log.Printf("error retrieving thread from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	// chirps hidden by a moderator or by authors the viewer blocked or muted
+	// stay in the tree as tombstones, so their replies keep their place
+	unavailable := map[uuid.UUID]bool{}
+	visibleChirps := []APIChirp{}
+	for _, row := range rows {
+		if row.Chirp.HiddenAt.Valid || row.MutedAuthor {
+			unavailable[row.Chirp.ID] = true
+			continue
+		}
+		visibleChirps = append(visibleChirps, NewAPIChirp(&row.Chirp))
+	}
+
+	if len(visibleChirps) == 0 {
+		err := fmt.Errorf("thread not found")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if err := cfg.loadChirpDetails(req.Context(), viewerID, visibleChirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiChirps := make([]APIChirp, len(rows))
+	visible := 0
+	for i, row := range rows {
+		if unavailable[row.Chirp.ID] {
+			apiChirps[i] = APIChirp{ID: row.Chirp.ID, InReplyToID: row.Chirp.InReplyToID}
+			continue
+		}
+		apiChirps[i] = visibleChirps[visible]
+		visible++
+	}
+
+	apiThread := NewAPIThread(rootID, apiChirps, unavailable)
+
+	respondWithJSON(wr, apiThread, http.StatusOK)
+}
+
+// loadChirpDetails fills in the fields of apiChirps that are aggregated from
+// other rows, using one query per detail for the whole batch. viewerID is the
+// authenticated caller, or uuid.Nil for anonymous requests.
+func (cfg *APIConfig) loadChirpDetails(ctx context.Context, viewerID uuid.UUID, apiChirps []APIChirp) error {
+	if len(apiChirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, len(apiChirps))
+	index := make(map[uuid.UUID]int, len(apiChirps))
+	for i, apiChirp := range apiChirps {
+		chirpIDs[i] = apiChirp.ID
+		index[apiChirp.ID] = i
+	}
+
+	replyCounts, err := cfg.DBQueries.GetReplyCounts(ctx, chirpIDs)
+	if err != nil {
+		return fmt.Errorf("error getting reply counts: %w", err)
+	}
+	for _, replyCount := range replyCounts {
+		if i, ok := index[replyCount.InReplyToID.UUID]; ok {
+			apiChirps[i].ReplyCount = replyCount.ReplyCount
+		}
+	}
+
+	quotedChirpIDs := []uuid.UUID{}
+	for _, apiChirp := range apiChirps {
+		if apiChirp.QuotedChirpID.Valid {
+			quotedChirpIDs = append(quotedChirpIDs, apiChirp.QuotedChirpID.UUID)
+		}
+	}
+	if len(quotedChirpIDs) > 0 {
+		quotedParams := database.GetChirpsByIDsParams{
+			ViewerID: viewerID,
+			ChirpIds: quotedChirpIDs,
+		}
+
+		rows, err := cfg.DBQueries.GetChirpsByIDs(ctx, quotedParams)
+		if err != nil {
+			return fmt.Errorf("error getting quoted chirps: %w", err)
+		}
+
+		// quotes of hidden chirps and of authors the viewer blocked or muted
+		// are left out like they are everywhere else
+		quoted := make(map[uuid.UUID]APIChirp, len(rows))
+		unavailable := map[uuid.UUID]bool{}
+		for _, row := range rows {
+			if row.Chirp.HiddenAt.Valid || row.MutedAuthor {
+				unavailable[row.Chirp.ID] = true
+				continue
+			}
+			quoted[row.Chirp.ID] = NewAPIChirp(&row.Chirp)
+		}
+
+		for i, apiChirp := range apiChirps {
+			if !apiChirp.QuotedChirpID.Valid {
+				continue
+			}
+			apiChirps[i].QuotedChirp = NewAPIQuotedChirp(apiChirp.QuotedChirpID.UUID, quoted, unavailable)
+		}
+	}
+
+	mentions, err := cfg.DBQueries.GetChirpMentions(ctx, chirpIDs)
+	if err != nil {
+		return fmt.Errorf("error getting chirp mentions: %w", err)
+	}
+	for _, dbMention := range mentions {
+		if i, ok := index[dbMention.ChirpID]; ok {
+			apiChirps[i].Mentions = append(apiChirps[i].Mentions, NewAPIMention(apiChirps[i].Body, &dbMention))
+		}
+	}
+
+	dbMedia, err := cfg.DBQueries.GetChirpMedia(ctx, chirpIDs)
+	if err != nil {
+		return fmt.Errorf("error getting chirp media: %w", err)
+	}
+	for _, m := range dbMedia {
+		if i, ok := index[m.ChirpID.UUID]; ok {
+			apiChirps[i].Media = append(apiChirps[i].Media, NewAPIMedia(&m, cfg.Storage))
+		}
+	}
+
+	polls, err := cfg.loadPolls(ctx, viewerID, chirpIDs)
+	if err != nil {
+		return err
+	}
+	for chirpID, poll := range polls {
+		apiChirps[index[chirpID]].Poll = poll
+	}
+
+	likeCounts, err := cfg.DBQueries.GetLikeCounts(ctx, chirpIDs)
+	if err != nil {
+		return fmt.Errorf("error getting like counts: %w", err)
+	}
+	for _, likeCount := range likeCounts {
+		if i, ok := index[likeCount.ChirpID]; ok {
+			apiChirps[i].LikeCount = likeCount.LikeCount
+		}
+	}
+
+	if viewerID != uuid.Nil {
+		likedChirpIDs, err := cfg.DBQueries.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewerID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("error getting liked chirps: %w", err)
+		}
+		for _, chirpID := range likedChirpIDs {
+			if i, ok := index[chirpID]; ok {
+				apiChirps[i].LikedByMe = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// cleanChirpBody checks the length of a chirp body and runs it through the
+// moderation pipeline, returning an error when the body is rejected. The
+// result's Text is the body to store.
+func (cfg *APIConfig) cleanChirpBody(body string) (moderation.Result, error) {
+	if len(body) > _MAX_CHIRP_LENGTH {
+		return moderation.Result{}, fmt.Errorf("chirp is too long, must be %d characters or less", _MAX_CHIRP_LENGTH)
+	}
+
+	if cfg.Moderator == nil {
+		return moderation.Result{Text: body}, nil
+	}
+
+	result := cfg.Moderator.Moderate(body)
+	if result.Rejected {
+		return result, fmt.Errorf("chirp contains words that are not allowed")
+	}
+
+	return result, nil
+}
+
+// flagChirp opens a report on dbChirp when moderation flagged its body, or
+// updates the open one
+func flagChirp(ctx context.Context, q *database.Queries, dbChirp *database.Chirp, result *moderation.Result) error {
+	if !result.Flagged {
+		return nil
+	}
+
+	flagParams := database.UpsertFlaggedChirpReportParams{
+		ReportedUserID: dbChirp.UserID,
+		ChirpID:        uuid.NullUUID{UUID: dbChirp.ID, Valid: true},
+		Details:        strings.Join(result.Words(moderation.ActionFlag), ", "),
+	}
+
+	if err := q.UpsertFlaggedChirpReport(ctx, flagParams); err != nil {
+		return fmt.Errorf("error flagging chirp: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/auth"
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+type accessTokenContextKey struct{}
+
+// accessToken is the outcome of checking a request's access token, either
+// its claims or why it was rejected
+type accessToken struct {
+	claims *auth.Claims
+	err    error
+}
+
+// accessTokenFromContext returns the access token check stored by
+// MiddlewareClaims
+func accessTokenFromContext(ctx context.Context) (accessToken, bool) {
+	token, ok := ctx.Value(accessTokenContextKey{}).(accessToken)
+	return token, ok
+}
+
+// claimsFromContext returns the claims of the valid access token stored by
+// MiddlewareClaims
+func claimsFromContext(ctx context.Context) (*auth.Claims, bool) {
+	token, ok := accessTokenFromContext(ctx)
+	if !ok || token.err != nil {
+		return nil, false
+	}
+	return token.claims, true
+}
+
+// MiddlewareClaims checks the bearer access token of every /api/ request
+// that sends one, once, and stores the outcome for handlers: the claims of
+// a valid token are available through claimsFromContext. Requests without a
+// valid access token are passed on regardless, it is up to the handler
+// whether that is an error. Some endpoints take a refresh token or api key
+// in the same header.
+func (cfg *APIConfig) MiddlewareClaims(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, "/api/") || req.Header.Get("Authorization") == "" {
+			next.ServeHTTP(wr, req)
+			return
+		}
+
+		claims, err := cfg.verifyAccessToken(req)
+
+		ctx := context.WithValue(req.Context(), accessTokenContextKey{}, accessToken{claims: claims, err: err})
+		next.ServeHTTP(wr, req.WithContext(ctx))
+	})
+}
+
+// verifyAccessToken returns the claims of the bearer access token on req
+// once its signature, audience and times check out and its session is still
+// active
+func (cfg *APIConfig) verifyAccessToken(req *http.Request) (*auth.Claims, error) {
+	accessToken, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := cfg.Keys.ParseJWT(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, sessionID, err := claimIDs(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.checkSessionActive(req.Context(), userID, sessionID); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// claimIDs returns the user and session an access token was issued for
+func claimIDs(claims *auth.Claims) (uuid.UUID, uuid.UUID, error) {
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("invalid subject in token: %w", err)
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("token was not issued for a session, log in again")
+	}
+
+	return userID, sessionID, nil
+}
+
+// makeAccessToken issues an access token for dbUser in sessionID that lasts
+// cfg.AccessTokenLifetime. Its roles and Chirpy Red status are a snapshot,
+// anything that grants access checks the database rather than the claims.
+func (cfg *APIConfig) makeAccessToken(ctx context.Context, q *database.Queries, dbUser *database.User, sessionID uuid.UUID) (string, error) {
+	userRoles, err := q.GetUserRoles(ctx, dbUser.ID)
+	if err != nil {
+		return "", fmt.Errorf("error getting user roles from database: %w", err)
+	}
+
+	custom := auth.CustomClaims{
+		SessionID:   sessionID.String(),
+		Roles:       userRoles,
+		IsChirpyRed: dbUser.IsChirpyRed,
+	}
+
+	return cfg.Keys.MakeJWT(dbUser.ID, cfg.AccessTokenLifetime, custom)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/auth"
+)
+
+func TestClaimIDs(t *testing.T) {
+	userID, sessionID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		claims  auth.Claims
+		wantErr bool
+	}{
+		{
+			name: "user and session",
+			claims: auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+				CustomClaims:     auth.CustomClaims{SessionID: sessionID.String()},
+			},
+		},
+		{
+			name: "no session",
+			claims: auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad subject",
+			claims: auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: "chirpy"},
+				CustomClaims:     auth.CustomClaims{SessionID: sessionID.String()},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID, gotSessionID, err := claimIDs(&tt.claims)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("claimIDs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (gotUserID != userID || gotSessionID != sessionID) {
+				t.Errorf("claimIDs() = %v, %v, want %v, %v", gotUserID, gotSessionID, userID, sessionID)
+			}
+		})
+	}
+}
+
+func TestMiddlewareClaimsPassesThrough(t *testing.T) {
+	cfg := &APIConfig{Keys: auth.NewHMACKeyring("secret")}
+
+	tests := []struct {
+		name        string
+		path        string
+		header      string
+		wantChecked bool
+	}{
+		{name: "no header", path: "/api/chirps"},
+		{name: "refresh token", path: "/api/chirps", header: "Bearer 0123456789abcdef", wantChecked: true},
+		{name: "api key", path: "/api/polka/webhooks", header: "ApiKey f271c81ff7084ee5b99a5091b42d486e", wantChecked: true},
+		{name: "static file", path: "/app/index.html", header: "Bearer 0123456789abcdef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			called := false
+			next := http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				called = true
+				if _, ok := claimsFromContext(req.Context()); ok {
+					t.Error("expected no claims in context")
+				}
+
+				// a failed check is kept too, so handlers do not repeat it
+				token, checked := accessTokenFromContext(req.Context())
+				if checked != tt.wantChecked || (checked && token.err == nil) {
+					t.Errorf("access token check = %+v, %v, want checked %v with an error", token, checked, tt.wantChecked)
+				}
+			})
+
+			cfg.MiddlewareClaims(next).ServeHTTP(httptest.NewRecorder(), req)
+			if !called {
+				t.Error("expected next handler to be called")
+			}
+		})
+	}
+}
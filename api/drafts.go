@@ -0,0 +1,423 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+const (
+	// _MAX_DRAFT_PUBLISH_ATTEMPTS is how many times the scheduler tries a
+	// draft that fails for reasons other than its contents
+	_MAX_DRAFT_PUBLISH_ATTEMPTS = 5
+	// _DRAFT_RETRY_BACKOFF is the wait before the first retry, doubling
+	// after every attempt
+	_DRAFT_RETRY_BACKOFF = time.Minute
+)
+
+// draftRetryBackoff is how long to wait before publishing a draft again
+// after attempts failed tries
+func draftRetryBackoff(attempts int32) time.Duration {
+	return _DRAFT_RETRY_BACKOFF << max(attempts-1, 0)
+}
+
+// draftRequest is a draft as clients send it: a new chirp plus when to
+// publish it, if it should be published at all
+type draftRequest struct {
+	chirpRequest
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// checkDraft runs the checks of createChirp that need no lookups, so a
+// draft that can never be published is refused when it is saved. The poll's
+// closing time is measured from when the draft will be published, so it is
+// only checked once the draft is scheduled.
+func (cfg *APIConfig) checkDraft(draftReq *draftRequest, now time.Time) error {
+	if err := checkChirpRequest(&draftReq.chirpRequest); err != nil {
+		return err
+	}
+
+	if _, err := cfg.cleanChirpBody(draftReq.Body); err != nil {
+		return err
+	}
+
+	if draftReq.PublishAt == nil {
+		return nil
+	}
+
+	if !draftReq.PublishAt.After(now) {
+		return fmt.Errorf("publish_at must be in the future")
+	}
+
+	if draftReq.Poll != nil {
+		if _, err := validatePoll(draftReq.Poll, *draftReq.PublishAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// draftColumns flattens draftReq into the columns a draft is stored in
+func draftColumns(draftReq *draftRequest) (mediaIDs []uuid.UUID, pollOptions []string, pollClosesAt, publishAt sql.NullTime) {
+	mediaIDs = append([]uuid.UUID{}, draftReq.MediaIDs...)
+	pollOptions = []string{}
+
+	if draftReq.Poll != nil {
+		pollOptions = append(pollOptions, draftReq.Poll.Options...)
+		pollClosesAt = sql.NullTime{Time: draftReq.Poll.ClosesAt.UTC(), Valid: true}
+	}
+
+	if draftReq.PublishAt != nil {
+		publishAt = sql.NullTime{Time: draftReq.PublishAt.UTC(), Valid: true}
+	}
+
+	return mediaIDs, pollOptions, pollClosesAt, publishAt
+}
+
+// draftChirpRequest is the chirp dbDraft publishes as
+func draftChirpRequest(dbDraft *database.Draft) chirpRequest {
+	chirpReq := chirpRequest{
+		Body:      dbDraft.Body,
+		InReplyTo: dbDraft.InReplyToID,
+		RechirpOf: dbDraft.RechirpOfID,
+		QuoteOf:   dbDraft.QuoteOfID,
+		MediaIDs:  dbDraft.MediaIds,
+	}
+
+	if len(dbDraft.PollOptions) > 0 {
+		chirpReq.Poll = &pollRequest{
+			Options:  dbDraft.PollOptions,
+			ClosesAt: dbDraft.PollClosesAt.Time,
+		}
+	}
+
+	return chirpReq
+}
+
+// HandlerCreateDraft POST /api/drafts
+func (cfg *APIConfig) HandlerCreateDraft(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	reqBody := draftRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		log.Printf("error decoding request body: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := cfg.checkDraft(&reqBody, time.Now()); err != nil {
+		log.Printf("error checking draft: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	mediaIDs, pollOptions, pollClosesAt, publishAt := draftColumns(&reqBody)
+
+	draftParams := database.CreateDraftParams{
+		UserID:       userID,
+		Body:         reqBody.Body,
+		InReplyToID:  reqBody.InReplyTo,
+		RechirpOfID:  reqBody.RechirpOf,
+		QuoteOfID:    reqBody.QuoteOf,
+		MediaIds:     mediaIDs,
+		PollOptions:  pollOptions,
+		PollClosesAt: pollClosesAt,
+		PublishAt:    publishAt,
+	}
+
+	dbDraft, err := cfg.DBQueries.CreateDraft(req.Context(), draftParams)
+	if err != nil {
+		log.Printf("error creating draft: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIDraft(&dbDraft), http.StatusCreated)
+}
+
+// HandlerGetDrafts GET /api/drafts
+func (cfg *APIConfig) HandlerGetDrafts(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(req.URL.Query())
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	draftsParams := database.GetDraftsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	dbDrafts, err := cfg.DBQueries.GetDrafts(req.Context(), draftsParams)
+	if err != nil {
+		log.Printf("error retrieving drafts from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIDraftPage(dbDrafts, page.Limit), http.StatusOK)
+}
+
+// HandlerGetDraft GET /api/drafts/{draftID}
+func (cfg *APIConfig) HandlerGetDraft(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	draftID, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		log.Printf("error parsing path {draftID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	draftParams := database.GetDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	}
+
+	dbDraft, err := cfg.DBQueries.GetDraft(req.Context(), draftParams)
+	if err != nil {
+		log.Printf("error getting draft from database: %v\n", err)
+		respondWithError(wr, fmt.Errorf("draft not found: %w", err), http.StatusNotFound)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIDraft(&dbDraft), http.StatusOK)
+}
+
+// HandlerUpdateDraft PUT /api/drafts/{draftID}
+//
+// Replaces the whole draft and clears the error of a failed publish.
+func (cfg *APIConfig) HandlerUpdateDraft(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	draftID, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		log.Printf("error parsing path {draftID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	reqBody := draftRequest{}
+
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		log.Printf("error decoding request body: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := cfg.checkDraft(&reqBody, time.Now()); err != nil {
+		log.Printf("error checking draft: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	mediaIDs, pollOptions, pollClosesAt, publishAt := draftColumns(&reqBody)
+
+	draftParams := database.UpdateDraftParams{
+		ID:           draftID,
+		UserID:       userID,
+		Body:         reqBody.Body,
+		InReplyToID:  reqBody.InReplyTo,
+		RechirpOfID:  reqBody.RechirpOf,
+		QuoteOfID:    reqBody.QuoteOf,
+		MediaIds:     mediaIDs,
+		PollOptions:  pollOptions,
+		PollClosesAt: pollClosesAt,
+		PublishAt:    publishAt,
+	}
+
+	// a draft being published stays locked until it is gone, so this either
+	// waits for the publish and finds nothing or runs before it
+	dbDraft, err := cfg.DBQueries.UpdateDraft(req.Context(), draftParams)
+	if err != nil {
+		log.Printf("error updating draft: %v\n", err)
+		respondWithError(wr, fmt.Errorf("draft not found: %w", err), http.StatusNotFound)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIDraft(&dbDraft), http.StatusOK)
+}
+
+// HandlerDeleteDraft DELETE /api/drafts/{draftID}
+func (cfg *APIConfig) HandlerDeleteDraft(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	draftID, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		log.Printf("error parsing path {draftID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	draftParams := database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	}
+
+	deleted, err := cfg.DBQueries.DeleteDraft(req.Context(), draftParams)
+	if err != nil {
+		log.Printf("error deleting draft: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	if deleted == 0 {
+		err := fmt.Errorf("draft not found")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// publishDueDraft publishes the earliest due draft no other instance is
+// publishing, reporting false when there is none left. The draft stays
+// locked until the chirp and the draft's deletion commit together, which is
+// what makes every draft publish exactly once. A draft that fails the
+// chirp's checks is unscheduled with the reason instead. Any other failure
+// is retried later, so one draft cannot hold up the ones due after it,
+// until _MAX_DRAFT_PUBLISH_ATTEMPTS unschedules it too.
+func (cfg *APIConfig) publishDueDraft(ctx context.Context) (bool, error) {
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.DBQueries.WithTx(tx)
+
+	dbDraft, err := qtx.GetDueDraftForUpdate(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error getting due draft: %w", err)
+	}
+
+	// a failed check can leave half a chirp behind, so it gets its own
+	// savepoint to fall back to before recording the failure
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT publish_draft"); err != nil {
+		return false, fmt.Errorf("error creating savepoint: %w", err)
+	}
+
+	chirpReq := draftChirpRequest(&dbDraft)
+
+	dbChirp, code, err := cfg.createChirp(ctx, qtx, dbDraft.UserID, &chirpReq)
+	if err != nil {
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT publish_draft"); err != nil {
+			return false, fmt.Errorf("error rolling back to savepoint: %w", err)
+		}
+
+		attempts := dbDraft.PublishAttempts + 1
+		if code >= http.StatusInternalServerError && attempts < _MAX_DRAFT_PUBLISH_ATTEMPTS {
+			backoff := draftRetryBackoff(attempts)
+			retryParams := database.RetryDraftParams{
+				ID:             dbDraft.ID,
+				BackoffSeconds: backoff.Seconds(),
+			}
+
+			if err := qtx.RetryDraft(ctx, retryParams); err != nil {
+				return false, fmt.Errorf("error rescheduling draft: %w", err)
+			}
+
+			log.Printf("draft %s not published, retrying in %v: %v\n", dbDraft.ID, backoff, err)
+			return true, tx.Commit()
+		}
+
+		failParams := database.FailDraftParams{
+			ID:           dbDraft.ID,
+			PublishError: err.Error(),
+		}
+
+		if err := qtx.FailDraft(ctx, failParams); err != nil {
+			return false, fmt.Errorf("error failing draft: %w", err)
+		}
+
+		log.Printf("draft %s not published: %v\n", dbDraft.ID, err)
+		return true, tx.Commit()
+	}
+
+	if err := qtx.DeletePublishedDraft(ctx, dbDraft.ID); err != nil {
+		return false, fmt.Errorf("error deleting published draft: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	log.Printf("draft %s published as chirp %s\n", dbDraft.ID, dbChirp.ID)
+	return true, nil
+}
+
+// PublishDueDrafts publishes every draft whose publish_at has passed and
+// returns how many it handled
+func (cfg *APIConfig) PublishDueDrafts(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		more, err := cfg.publishDueDraft(ctx)
+		if err != nil || !more {
+			return published, err
+		}
+		published++
+	}
+}
+
+// RunDraftPublisher calls PublishDueDrafts every interval until ctx is done.
+// Any number of instances can run it against the same database.
+func (cfg *APIConfig) RunDraftPublisher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := cfg.PublishDueDrafts(ctx); err != nil {
+				log.Printf("error running draft publisher: %v\n", err)
+			}
+		}
+	}
+}
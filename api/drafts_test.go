@@ -0,0 +1,106 @@
+package api
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+func TestCheckDraft(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	inAnHour := now.Add(time.Hour)
+	chirpID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+
+	tests := []struct {
+		name    string
+		draft   draftRequest
+		wantErr bool
+	}{
+		{
+			name:  "Unscheduled",
+			draft: draftRequest{chirpRequest: chirpRequest{Body: "later"}},
+		},
+		{
+			name:  "Scheduled",
+			draft: draftRequest{chirpRequest: chirpRequest{Body: "later"}, PublishAt: &inAnHour},
+		},
+		{
+			name:    "Scheduled in the past",
+			draft:   draftRequest{chirpRequest: chirpRequest{Body: "later"}, PublishAt: &now},
+			wantErr: true,
+		},
+		{
+			name:    "Rechirp with a body",
+			draft:   draftRequest{chirpRequest: chirpRequest{Body: "later", RechirpOf: chirpID}},
+			wantErr: true,
+		},
+		{
+			name: "Poll closing after publish",
+			draft: draftRequest{
+				chirpRequest: chirpRequest{Body: "later", Poll: &pollRequest{Options: []string{"yes", "no"}, ClosesAt: inAnHour.Add(time.Hour)}},
+				PublishAt:    &inAnHour,
+			},
+		},
+		{
+			name: "Poll closing before publish",
+			draft: draftRequest{
+				chirpRequest: chirpRequest{Body: "later", Poll: &pollRequest{Options: []string{"yes", "no"}, ClosesAt: now.Add(30 * time.Minute)}},
+				PublishAt:    &inAnHour,
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Unscheduled poll without a closing time",
+			draft: draftRequest{chirpRequest: chirpRequest{Body: "later", Poll: &pollRequest{Options: []string{"yes", "no"}}}},
+		},
+	}
+
+	cfg := &APIConfig{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := cfg.checkDraft(&tt.draft, now); (err != nil) != tt.wantErr {
+				t.Errorf("checkDraft() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDraftChirpRequest(t *testing.T) {
+	closesAt := time.Date(2025, time.January, 2, 12, 0, 0, 0, time.UTC)
+
+	withPoll := draftChirpRequest(&database.Draft{
+		Body:         "later",
+		MediaIds:     []uuid.UUID{},
+		PollOptions:  []string{"yes", "no"},
+		PollClosesAt: sql.NullTime{Time: closesAt, Valid: true},
+	})
+	if withPoll.Poll == nil || len(withPoll.Poll.Options) != 2 || !withPoll.Poll.ClosesAt.Equal(closesAt) {
+		t.Errorf("draftChirpRequest() poll = %+v, want 2 options closing at %v", withPoll.Poll, closesAt)
+	}
+
+	withoutPoll := draftChirpRequest(&database.Draft{Body: "later", MediaIds: []uuid.UUID{}, PollOptions: []string{}})
+	if withoutPoll.Poll != nil {
+		t.Errorf("draftChirpRequest() poll = %+v, want none", withoutPoll.Poll)
+	}
+}
+
+func TestDraftRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 4, want: 8 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := draftRetryBackoff(tt.attempts); got != tt.want {
+			t.Errorf("draftRetryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
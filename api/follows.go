@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+	"github.com/mmycroft/boot-dev-chirpy/storage"
+)
+
+// HandlerFollowUser POST /api/users/{userID}/follow
+func (cfg *APIConfig) HandlerFollowUser(wr http.ResponseWriter, req *http.Request) {
+	followerID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	followeeID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if followerID == followeeID {
+		err := fmt.Errorf("users cannot follow themselves")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := cfg.DBQueries.GetUserByID(req.Context(), followeeID); err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if err := cfg.checkNotBlocked(req.Context(), followeeID, followerID); err != nil {
+		log.Printf("error checking block: %v\n", err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	followParams := database.CreateFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}
+
+	if err := cfg.DBQueries.CreateFollow(req.Context(), followParams); err != nil {
+		log.Printf("error creating follow: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerUnfollowUser DELETE /api/users/{userID}/follow
+func (cfg *APIConfig) HandlerUnfollowUser(wr http.ResponseWriter, req *http.Request) {
+	followerID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	followeeID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	followParams := database.DeleteFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}
+
+	if err := cfg.DBQueries.DeleteFollow(req.Context(), followParams); err != nil {
+		log.Printf("error deleting follow: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerGetFollowers GET /api/users/{userID}/followers
+func (cfg *APIConfig) HandlerGetFollowers(wr http.ResponseWriter, req *http.Request) {
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if _, err := cfg.DBQueries.GetUserByID(req.Context(), userID); err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	page, err := parsePageParams(req.URL.Query())
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	followersParams := database.GetFollowersParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	rows, err := cfg.DBQueries.GetFollowers(req.Context(), followersParams)
+	if err != nil {
+		log.Printf("error retrieving followers from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	followers := make([]relatedUser, len(rows))
+	for i, row := range rows {
+		followers[i] = relatedUser{
+			User:  row.User,
+			Since: row.FollowedAt,
+		}
+	}
+
+	respondWithJSON(wr, newRelatedUserPage(followers, page.Limit, cfg.Storage), http.StatusOK)
+}
+
+// HandlerGetFollowing GET /api/users/{userID}/following
+func (cfg *APIConfig) HandlerGetFollowing(wr http.ResponseWriter, req *http.Request) {
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if _, err := cfg.DBQueries.GetUserByID(req.Context(), userID); err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	page, err := parsePageParams(req.URL.Query())
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	followingParams := database.GetFollowingParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	rows, err := cfg.DBQueries.GetFollowing(req.Context(), followingParams)
+	if err != nil {
+		log.Printf("error retrieving following from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	following := make([]relatedUser, len(rows))
+	for i, row := range rows {
+		following[i] = relatedUser{
+			User:  row.User,
+			Since: row.FollowedAt,
+		}
+	}
+
+	respondWithJSON(wr, newRelatedUserPage(following, page.Limit, cfg.Storage), http.StatusOK)
+}
+
+// relatedUser is the other side of a follow, block or mute together with
+// when it happened
+type relatedUser struct {
+	User  database.User
+	Since time.Time
+}
+
+func newRelatedUserPage(related []relatedUser, limit int32, store storage.Storage) APIProfilePage {
+	related, cursor := nextCursor(related, limit, func(r relatedUser) pageCursor {
+		return pageCursor{CreatedAt: r.Since, ID: r.User.ID}
+	})
+
+	apiProfiles := make([]APIProfile, len(related))
+	for i, r := range related {
+		apiProfiles[i] = NewAPIProfile(&r.User, store)
+	}
+
+	return APIProfilePage{
+		Users:      apiProfiles,
+		NextCursor: cursor,
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+const _MAX_TAG_LENGTH = 64
+
+// extractHashtags returns the normalized (lower case) #hashtags in body in
+// the order they first appear. A hashtag is a # at the start of a word
+// followed by letters, digits or underscores, and must contain at least one
+// letter so "#1" is not a tag.
+func extractHashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && !isTagBoundary(runes[i-1])) {
+			continue
+		}
+
+		j := i + 1
+		for j < len(runes) && isTagRune(runes[j]) {
+			j++
+		}
+
+		tag := normalizeTag(string(runes[i+1 : j]))
+		i = j - 1
+
+		if tag == "" || len([]rune(tag)) > _MAX_TAG_LENGTH || !strings.ContainsFunc(tag, unicode.IsLetter) || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// normalizeTag lower cases a tag and strips a leading #
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// isTagBoundary reports whether a tag may start right after r, so that
+// URL fragments like example.com/#frag are not picked up
+func isTagBoundary(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("([{\"'", r)
+}
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// syncChirpTags replaces the tags stored for dbChirp with the hashtags in its body
+func syncChirpTags(ctx context.Context, q *database.Queries, dbChirp *database.Chirp) error {
+	if err := q.DeleteChirpTags(ctx, dbChirp.ID); err != nil {
+		return fmt.Errorf("error deleting chirp tags: %w", err)
+	}
+
+	for _, name := range extractHashtags(dbChirp.Body) {
+		tag, err := q.UpsertTag(ctx, name)
+		if err != nil {
+			return fmt.Errorf("error upserting tag %q: %w", name, err)
+		}
+
+		chirpTagParams := database.CreateChirpTagParams{
+			ChirpID:   dbChirp.ID,
+			TagID:     tag.ID,
+			CreatedAt: dbChirp.CreatedAt,
+		}
+
+		if err := q.CreateChirpTag(ctx, chirpTagParams); err != nil {
+			return fmt.Errorf("error creating chirp tag %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "No hashtags",
+			body: "just a chirp",
+			want: []string{},
+		},
+		{
+			name: "Normalized and deduplicated",
+			body: "#Go is great, #go #GO!",
+			want: []string{"go"},
+		},
+		{
+			name: "Punctuation ends a tag",
+			body: "loving #boot_dev, and #chirpy.",
+			want: []string{"boot_dev", "chirpy"},
+		},
+		{
+			name: "Unicode letters",
+			body: "#Café time",
+			want: []string{"café"},
+		},
+		{
+			name: "Numbers and anchors are not tags",
+			body: "issue #42 and example.com/#frag and a#b",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractHashtags(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractHashtags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+// HandlerLikeChirp PUT /api/chirps/{chirpID}/like
+func (cfg *APIConfig) HandlerLikeChirp(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	dbChirp, err := cfg.DBQueries.GetChirp(req.Context(), chirpID)
+	if err != nil {
+		log.Printf("error getting chirp from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if err := cfg.checkNotBlocked(req.Context(), dbChirp.UserID, userID); err != nil {
+		log.Printf("error checking block: %v\n", err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	likeParams := database.CreateChirpLikeParams{
+		ChirpID: chirpID,
+		UserID:  userID,
+	}
+
+	if err := cfg.DBQueries.CreateChirpLike(req.Context(), likeParams); err != nil {
+		log.Printf("error creating chirp like: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerUnlikeChirp DELETE /api/chirps/{chirpID}/like
+func (cfg *APIConfig) HandlerUnlikeChirp(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	likeParams := database.DeleteChirpLikeParams{
+		ChirpID: chirpID,
+		UserID:  userID,
+	}
+
+	if err := cfg.DBQueries.DeleteChirpLike(req.Context(), likeParams); err != nil {
+		log.Printf("error deleting chirp like: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerGetUserLikes GET /api/users/{userID}/likes
+func (cfg *APIConfig) HandlerGetUserLikes(wr http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.optionalAuthenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if _, err := cfg.DBQueries.GetUserByID(req.Context(), userID); err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	page, err := parsePageParams(req.URL.Query())
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	likedParams := database.GetLikedChirpsParams{
+		UserID:          userID,
+		ViewerID:        viewerID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	rows, err := cfg.DBQueries.GetLikedChirps(req.Context(), likedParams)
+	if err != nil {
+		log.Printf("error retrieving liked chirps from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	rows, cursor := nextCursor(rows, page.Limit, func(row database.GetLikedChirpsRow) pageCursor {
+		return pageCursor{CreatedAt: row.LikedAt, ID: row.Chirp.ID}
+	})
+
+	apiChirps := make([]APIChirp, len(rows))
+	for i, row := range rows {
+		apiChirps[i] = NewAPIChirp(&row.Chirp)
+	}
+
+	if err := cfg.loadChirpDetails(req.Context(), viewerID, apiChirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiChirpPage := APIChirpPage{
+		Chirps:     apiChirps,
+		NextCursor: cursor,
+	}
+
+	respondWithJSON(wr, apiChirpPage, http.StatusOK)
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+	"github.com/mmycroft/boot-dev-chirpy/media"
+)
+
+const (
+	_MAX_CHIRP_MEDIA      = 4
+	_MAX_ALT_TEXT_LENGTH  = 1000
+	_MEDIA_THUMBNAIL_SIZE = 400
+	// _MULTIPART_OVERHEAD leaves room for the form around the file
+	_MULTIPART_OVERHEAD = 64 << 10
+
+	// _MEDIA_CLEANUP_BATCH is how many uploads one cleanup query deletes
+	_MEDIA_CLEANUP_BATCH = 100
+)
+
+// checkMediaIDs checks the media a new chirp wants to attach, before any of
+// it is looked up
+func checkMediaIDs(mediaIDs []uuid.UUID) error {
+	if len(mediaIDs) > _MAX_CHIRP_MEDIA {
+		return fmt.Errorf("a chirp can have at most %d media attachments", _MAX_CHIRP_MEDIA)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(mediaIDs))
+	for _, mediaID := range mediaIDs {
+		if seen[mediaID] {
+			return fmt.Errorf("media %s is attached more than once", mediaID)
+		}
+		seen[mediaID] = true
+	}
+
+	return nil
+}
+
+// errMediaUnavailable is returned by attachChirpMedia for media that is not
+// the author's to attach
+var errMediaUnavailable = errors.New("media not found or already attached")
+
+// attachChirpMedia attaches the uploads in mediaIDs to dbChirp in order.
+// Each must belong to the chirp's author and not be on another chirp yet.
+func attachChirpMedia(ctx context.Context, q *database.Queries, dbChirp *database.Chirp, mediaIDs []uuid.UUID) error {
+	for i, mediaID := range mediaIDs {
+		attachParams := database.AttachMediaParams{
+			ChirpID:  uuid.NullUUID{UUID: dbChirp.ID, Valid: true},
+			Position: sql.NullInt32{Int32: int32(i), Valid: true},
+			ID:       mediaID,
+			UserID:   dbChirp.UserID,
+		}
+
+		attached, err := q.AttachMedia(ctx, attachParams)
+		if err != nil {
+			return fmt.Errorf("error attaching media: %w", err)
+		}
+		if attached == 0 {
+			return fmt.Errorf("media %s: %w", mediaID, errMediaUnavailable)
+		}
+	}
+
+	return nil
+}
+
+// deleteStoredMedia removes the files behind dbMedia once their rows are
+// gone. Failures only leave orphaned files, so they are logged and skipped.
+func (cfg *APIConfig) deleteStoredMedia(ctx context.Context, dbMedia []database.MediaAttachment) {
+	for _, m := range dbMedia {
+		for _, key := range []string{m.StorageKey, m.ThumbnailKey} {
+			if err := cfg.Storage.Delete(ctx, key); err != nil {
+				log.Printf("error deleting stored media %s: %v\n", key, err)
+			}
+		}
+	}
+}
+
+// errUploadTooLarge is returned by readUpload for files over media.MaxSize
+var errUploadTooLarge = fmt.Errorf("upload is too large, must be %d bytes or less", media.MaxSize)
+
+// readUpload reads the file sent in the "file" field of a multipart form
+func readUpload(wr http.ResponseWriter, req *http.Request) ([]byte, error) {
+	req.Body = http.MaxBytesReader(wr, req.Body, media.MaxSize+_MULTIPART_OVERHEAD)
+
+	file, _, err := req.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, errUploadTooLarge
+		}
+		return nil, fmt.Errorf("error reading form file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading form file: %w", err)
+	}
+
+	if len(data) > media.MaxSize {
+		return nil, errUploadTooLarge
+	}
+
+	return data, nil
+}
+
+// HandlerUploadMedia POST /api/media
+//
+// Takes a multipart form with the image in "file" and an optional
+// "alt_text". The returned id can be attached to a chirp in media_ids.
+func (cfg *APIConfig) HandlerUploadMedia(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	if err := cfg.checkNotSuspended(req.Context(), userID); err != nil {
+		log.Printf("error checking suspension: %v\n", err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	data, err := readUpload(wr, req)
+	if err != nil {
+		log.Printf("error reading upload: %v\n", err)
+		code := http.StatusBadRequest
+		if errors.Is(err, errUploadTooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		respondWithError(wr, err, code)
+		return
+	}
+
+	altText := req.FormValue("alt_text")
+	if utf8.RuneCountInString(altText) > _MAX_ALT_TEXT_LENGTH {
+		err := fmt.Errorf("alt_text must be %d characters or less", _MAX_ALT_TEXT_LENGTH)
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	img, err := media.Process(data)
+	if err != nil {
+		log.Printf("error processing media: %v\n", err)
+		code := http.StatusBadRequest
+		if errors.Is(err, media.ErrUnsupportedType) {
+			code = http.StatusUnsupportedMediaType
+		}
+		respondWithError(wr, err, code)
+		return
+	}
+
+	thumbnail, thumbnailContentType, err := media.Encode(media.Thumbnail(img.Image, _MEDIA_THUMBNAIL_SIZE), img.ContentType)
+	if err != nil {
+		log.Printf("error creating thumbnail: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	mediaID := uuid.New()
+
+	mediaParams := database.CreateMediaAttachmentParams{
+		ID:                   mediaID,
+		UserID:               userID,
+		ContentType:          img.ContentType,
+		SizeBytes:            int64(len(img.Data)),
+		Width:                int32(img.Width),
+		Height:               int32(img.Height),
+		AltText:              altText,
+		StorageKey:           fmt.Sprintf("media/%s%s", mediaID, img.Extension()),
+		ThumbnailKey:         fmt.Sprintf("media/%s_thumb%s", mediaID, media.Extension(thumbnailContentType)),
+		ThumbnailContentType: thumbnailContentType,
+	}
+
+	stored := []database.MediaAttachment{{StorageKey: mediaParams.StorageKey, ThumbnailKey: mediaParams.ThumbnailKey}}
+
+	if err := cfg.Storage.Put(req.Context(), mediaParams.StorageKey, bytes.NewReader(img.Data), img.ContentType); err != nil {
+		log.Printf("error storing media: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := cfg.Storage.Put(req.Context(), mediaParams.ThumbnailKey, bytes.NewReader(thumbnail), thumbnailContentType); err != nil {
+		log.Printf("error storing media thumbnail: %v\n", err)
+		cfg.deleteStoredMedia(req.Context(), stored)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	dbMedia, err := cfg.DBQueries.CreateMediaAttachment(req.Context(), mediaParams)
+	if err != nil {
+		log.Printf("error creating media attachment: %v\n", err)
+		cfg.deleteStoredMedia(req.Context(), stored)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIMedia(&dbMedia, cfg.Storage), http.StatusCreated)
+}
+
+// DeleteUnattachedMedia deletes uploads older than maxAge that no chirp or
+// draft uses, along with their files, and returns how many it deleted
+func (cfg *APIConfig) DeleteUnattachedMedia(ctx context.Context, maxAge time.Duration) (int, error) {
+	deleted := 0
+	for {
+		cleanupParams := database.DeleteUnattachedMediaParams{
+			MaxAgeSeconds: maxAge.Seconds(),
+			Limit:         _MEDIA_CLEANUP_BATCH,
+		}
+
+		dbMedia, err := cfg.DBQueries.DeleteUnattachedMedia(ctx, cleanupParams)
+		if err != nil {
+			return deleted, fmt.Errorf("error deleting unattached media: %w", err)
+		}
+
+		cfg.deleteStoredMedia(ctx, dbMedia)
+
+		deleted += len(dbMedia)
+		if len(dbMedia) < _MEDIA_CLEANUP_BATCH {
+			return deleted, nil
+		}
+	}
+}
+
+// RunMediaCleanup calls DeleteUnattachedMedia every interval until ctx is
+// done. Uploads being attached by an in-flight chirp are skipped.
+func (cfg *APIConfig) RunMediaCleanup(ctx context.Context, interval, maxAge time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := cfg.DeleteUnattachedMedia(ctx, maxAge)
+			if err != nil {
+				log.Printf("error running media cleanup: %v\n", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("deleted %d unattached uploads\n", deleted)
+			}
+		}
+	}
+}
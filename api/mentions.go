@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+// handlePattern is the shape of a user handle, shared by handle validation
+// and @mention parsing
+const handlePattern = `[A-Za-z0-9_]{1,30}`
+
+var (
+	handleRegexp  = regexp.MustCompile(`^` + handlePattern + `$`)
+	mentionRegexp = regexp.MustCompile(`(^|[^A-Za-z0-9_@])@(` + handlePattern + `)\b`)
+)
+
+// mention is an @handle in a chirp body. Start and End are code point
+// offsets of the "@handle" text, End exclusive.
+type mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// extractMentions returns the @handle mentions in body in order
+func extractMentions(body string) []mention {
+	mentions := []mention{}
+
+	for _, m := range mentionRegexp.FindAllStringSubmatchIndex(body, -1) {
+		// \b only knows ASCII, so "@zoë" would otherwise mention "zo"
+		if next, _ := utf8.DecodeRuneInString(body[m[5]:]); unicode.IsLetter(next) || unicode.IsDigit(next) {
+			continue
+		}
+
+		// m[4]:m[5] is the handle, the @ sits right before it
+		start := len([]rune(body[:m[4]-1]))
+		handle := body[m[4]:m[5]]
+		mentions = append(mentions, mention{
+			Handle: handle,
+			Start:  start,
+			End:    start + 1 + len([]rune(handle)),
+		})
+	}
+
+	return mentions
+}
+
+// syncChirpMentions replaces the mentions stored for dbChirp with the
+// @handles in its body that belong to a user. Unknown handles, and users who
+// have blocked the author, are left as plain text.
+func syncChirpMentions(ctx context.Context, q *database.Queries, dbChirp *database.Chirp) error {
+	if err := q.DeleteChirpMentions(ctx, dbChirp.ID); err != nil {
+		return fmt.Errorf("error deleting chirp mentions: %w", err)
+	}
+
+	mentions := extractMentions(dbChirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	handles := make([]string, len(mentions))
+	for i, m := range mentions {
+		handles[i] = strings.ToLower(m.Handle)
+	}
+
+	dbUsers, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return fmt.Errorf("error resolving mentioned handles: %w", err)
+	}
+
+	userIDs := make([]uuid.UUID, len(dbUsers))
+	for i, dbUser := range dbUsers {
+		userIDs[i] = dbUser.ID
+	}
+
+	blockerParams := database.GetBlockerIDsParams{
+		BlockedID:  dbChirp.UserID,
+		BlockerIds: userIDs,
+	}
+
+	blockerIDs, err := q.GetBlockerIDs(ctx, blockerParams)
+	if err != nil {
+		return fmt.Errorf("error checking mentioned users' blocks: %w", err)
+	}
+
+	users := make(map[string]database.User, len(dbUsers))
+	for _, dbUser := range dbUsers {
+		if !slices.Contains(blockerIDs, dbUser.ID) {
+			users[strings.ToLower(dbUser.Handle)] = dbUser
+		}
+	}
+
+	for _, m := range mentions {
+		dbUser, ok := users[strings.ToLower(m.Handle)]
+		if !ok {
+			continue
+		}
+
+		mentionParams := database.CreateChirpMentionParams{
+			ChirpID:     dbChirp.ID,
+			UserID:      dbUser.ID,
+			StartOffset: int32(m.Start),
+			EndOffset:   int32(m.End),
+		}
+
+		if err := q.CreateChirpMention(ctx, mentionParams); err != nil {
+			return fmt.Errorf("error creating chirp mention of %q: %w", m.Handle, err)
+		}
+	}
+
+	return nil
+}
+
+// HandlerGetMyMentions GET /api/users/me/mentions
+func (cfg *APIConfig) HandlerGetMyMentions(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(req.URL.Query())
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	mentionsParams := database.GetMentioningChirpsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	dbChirps, err := cfg.DBQueries.GetMentioningChirps(req.Context(), mentionsParams)
+	if err != nil {
+		log.Printf("error retrieving mentions from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiChirpPage := NewAPIChirpPage(dbChirps, page.Limit)
+
+	if err := cfg.loadChirpDetails(req.Context(), userID, apiChirpPage.Chirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, apiChirpPage, http.StatusOK)
+}
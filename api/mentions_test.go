@@ -0,0 +1,58 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []mention
+	}{
+		{
+			name: "No mentions",
+			body: "hello world",
+			want: []mention{},
+		},
+		{
+			name: "Mentions with punctuation",
+			body: "@alice hi, (@Bob_2)!",
+			want: []mention{
+				{Handle: "alice", Start: 0, End: 6},
+				{Handle: "Bob_2", Start: 12, End: 18},
+			},
+		},
+		{
+			name: "Offsets count code points",
+			body: "héllo @zoe",
+			want: []mention{
+				{Handle: "zoe", Start: 6, End: 10},
+			},
+		},
+		{
+			name: "Non-ASCII letters end the handle",
+			body: "@zoë",
+			want: []mention{},
+		},
+		{
+			name: "Emails are not mentions",
+			body: "mail me at me@example.com",
+			want: []mention{},
+		},
+		{
+			name: "Handles longer than 30 are ignored",
+			body: "@abcdefghijklmnopqrstuvwxyz012345",
+			want: []mention{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractMentions(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractMentions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
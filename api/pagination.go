@@ -0,0 +1,143 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	_DEFAULT_PAGE_LIMIT = 20
+	_MAX_PAGE_LIMIT     = 100
+)
+
+// pageCursor is the position of the last item on a page, keyed on the
+// (created_at, id) pair every paginated query orders by. Search results are
+// ordered by rank first, so their cursors carry the rank as well.
+type pageCursor struct {
+	Rank      float64   `json:"r,omitempty"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// encodeCursor turns a cursor into the opaque string handed to clients
+func encodeCursor(cursor pageCursor) string {
+	b, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor parses a cursor previously produced by encodeCursor
+func decodeCursor(s string) (pageCursor, error) {
+	cursor := pageCursor{}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return cursor, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	if cursor.ID == uuid.Nil || cursor.CreatedAt.IsZero() {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+// pageParams holds the limit and cursor shared by every paginated endpoint
+type pageParams struct {
+	Limit           int32
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+}
+
+// parsePageParams reads ?limit= and ?cursor= from the query string
+func parsePageParams(query url.Values) (pageParams, error) {
+	params := pageParams{}
+
+	limit, err := parseLimit(query, _DEFAULT_PAGE_LIMIT)
+	if err != nil {
+		return params, err
+	}
+	params.Limit = limit
+
+	if s := query.Get("cursor"); s != "" {
+		cursor, err := decodeCursor(s)
+		if err != nil {
+			return params, err
+		}
+		params.CursorRank = sql.NullFloat64{Float64: cursor.Rank, Valid: true}
+		params.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	return params, nil
+}
+
+// parseLimit reads ?limit= from the query string, returning fallback when it is absent
+func parseLimit(query url.Values, fallback int32) (int32, error) {
+	s := query.Get("limit")
+	if s == "" {
+		return fallback, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > _MAX_PAGE_LIMIT {
+		return 0, fmt.Errorf("limit must be between 1 and %d", _MAX_PAGE_LIMIT)
+	}
+
+	return int32(limit), nil
+}
+
+// parseOptionalUUID reads a UUID query parameter, returning an invalid NullUUID when it is absent
+func parseOptionalUUID(query url.Values, key string) (uuid.NullUUID, error) {
+	s := query.Get(key)
+	if s == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
+
+// parseOptionalTime reads an RFC 3339 query parameter, returning an invalid NullTime when it is absent
+func parseOptionalTime(query url.Values, key string) (sql.NullTime, error) {
+	s := query.Get(key)
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+// nextCursor returns the cursor for the page after items, or "" when the
+// query fetched no more than limit rows. Queries are run with limit+1 so
+// the extra row signals that another page exists.
+func nextCursor[T any](items []T, limit int32, key func(T) pageCursor) ([]T, string) {
+	if len(items) <= int(limit) {
+		return items, ""
+	}
+	items = items[:limit]
+	return items, encodeCursor(key(items[len(items)-1]))
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := pageCursor{
+		CreatedAt: time.Date(2025, 8, 1, 12, 30, 0, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	got, err := decodeCursor(encodeCursor(cursor))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !got.CreatedAt.Equal(cursor.CreatedAt) || got.ID != cursor.ID {
+		t.Errorf("decodeCursor() = %v, want %v", got, cursor)
+	}
+}
+
+func TestParsePageParams(t *testing.T) {
+	validCursor := encodeCursor(pageCursor{CreatedAt: time.Now(), ID: uuid.New()})
+
+	tests := []struct {
+		name       string
+		query      url.Values
+		wantLimit  int32
+		wantCursor bool
+		wantErr    bool
+	}{
+		{
+			name:      "Defaults",
+			query:     url.Values{},
+			wantLimit: _DEFAULT_PAGE_LIMIT,
+		},
+		{
+			name:       "Limit and cursor",
+			query:      url.Values{"limit": {"5"}, "cursor": {validCursor}},
+			wantLimit:  5,
+			wantCursor: true,
+		},
+		{
+			name:    "Limit too large",
+			query:   url.Values{"limit": {"1000"}},
+			wantErr: true,
+		},
+		{
+			name:    "Limit not a number",
+			query:   url.Values{"limit": {"ten"}},
+			wantErr: true,
+		},
+		{
+			name:    "Garbage cursor",
+			query:   url.Values{"cursor": {"not-a-cursor"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePageParams(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePageParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Limit != tt.wantLimit {
+				t.Errorf("parsePageParams() limit = %d, want %d", got.Limit, tt.wantLimit)
+			}
+			if got.CursorID.Valid != tt.wantCursor || got.CursorCreatedAt.Valid != tt.wantCursor {
+				t.Errorf("parsePageParams() cursor valid = %v, want %v", got.CursorID.Valid, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	items := []int{1, 2, 3}
+	key := func(i int) pageCursor {
+		return pageCursor{CreatedAt: time.Unix(int64(i), 0).UTC(), ID: uuid.New()}
+	}
+
+	page, cursor := nextCursor(items, 3, key)
+	if len(page) != 3 || cursor != "" {
+		t.Errorf("expected full page without cursor, got %d items and cursor %q", len(page), cursor)
+	}
+
+	page, cursor = nextCursor(items, 2, key)
+	if len(page) != 2 || cursor == "" {
+		t.Fatalf("expected 2 items and a cursor, got %d items and cursor %q", len(page), cursor)
+	}
+
+	decoded, err := decodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if decoded.CreatedAt.Unix() != 2 {
+		t.Errorf("expected cursor at last item on page, got %v", decoded.CreatedAt)
+	}
+}
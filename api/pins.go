@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+// HandlerPinChirp PUT /api/chirps/{chirpID}/pin
+//
+// Pins one of the caller's own chirps to their profile, replacing any
+// chirp pinned before.
+func (cfg *APIConfig) HandlerPinChirp(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	dbChirp, err := cfg.DBQueries.GetChirp(req.Context(), chirpID)
+	if err != nil || dbChirp.HiddenAt.Valid {
+		err := fmt.Errorf("chirp not found")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if userID != dbChirp.UserID {
+		err := fmt.Errorf("request user id does not match chirp user id")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	pinParams := database.PinChirpParams{
+		ID:            userID,
+		PinnedChirpID: uuid.NullUUID{UUID: chirpID, Valid: true},
+	}
+
+	dbUser, err := cfg.DBQueries.PinChirp(req.Context(), pinParams)
+	if err != nil {
+		log.Printf("error pinning chirp: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIUser(&dbUser, cfg.Storage, "", ""), http.StatusOK)
+}
+
+// HandlerUnpinChirp DELETE /api/chirps/{chirpID}/pin
+//
+// Unpinning a chirp that is not pinned succeeds without changing anything.
+func (cfg *APIConfig) HandlerUnpinChirp(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	dbChirp, err := cfg.DBQueries.GetChirp(req.Context(), chirpID)
+	if err != nil {
+		log.Printf("error getting chirp from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if userID != dbChirp.UserID {
+		err := fmt.Errorf("request user id does not match chirp user id")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	unpinParams := database.UnpinChirpParams{
+		ID:            userID,
+		PinnedChirpID: uuid.NullUUID{UUID: chirpID, Valid: true},
+	}
+
+	if err := cfg.DBQueries.UnpinChirp(req.Context(), unpinParams); err != nil {
+		log.Printf("error unpinning chirp: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// pinnedChirpID returns the id of the chirp authorID has pinned, if any
+func (cfg *APIConfig) pinnedChirpID(ctx context.Context, authorID uuid.UUID) (uuid.NullUUID, error) {
+	dbUser, err := cfg.DBQueries.GetUserByID(ctx, authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.NullUUID{}, nil
+	}
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("error getting user from database: %w", err)
+	}
+
+	return dbUser.PinnedChirpID, nil
+}
+
+// pinnedChirp returns the pinned chirp pinnedID when viewerID may see it in
+// a list filtered to between since and until, nil otherwise
+func (cfg *APIConfig) pinnedChirp(ctx context.Context, pinnedID, viewerID uuid.UUID, since, until sql.NullTime, includeHidden bool) (*database.Chirp, error) {
+	dbChirp, err := cfg.DBQueries.GetChirp(ctx, pinnedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting pinned chirp from database: %w", err)
+	}
+
+	if dbChirp.HiddenAt.Valid && !includeHidden {
+		return nil, nil
+	}
+
+	if (since.Valid && dbChirp.CreatedAt.Before(since.Time)) || (until.Valid && !dbChirp.CreatedAt.Before(until.Time)) {
+		return nil, nil
+	}
+
+	if viewerID != uuid.Nil {
+		excludedParams := database.IsExcludedByViewerParams{
+			ViewerID: viewerID,
+			UserID:   dbChirp.UserID,
+		}
+
+		excluded, err := cfg.DBQueries.IsExcludedByViewer(ctx, excludedParams)
+		if err != nil {
+			return nil, fmt.Errorf("error checking exclusion: %w", err)
+		}
+		if excluded {
+			return nil, nil
+		}
+	}
+
+	return &dbChirp, nil
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+const (
+	_MIN_POLL_OPTIONS       = 2
+	_MAX_POLL_OPTIONS       = 4
+	_MAX_POLL_OPTION_LENGTH = 25
+	_MIN_POLL_DURATION      = 5 * time.Minute
+	_MAX_POLL_DURATION      = 7 * 24 * time.Hour
+
+	// _POLL_CLOSE_BATCH is how many polls one closer query finalizes
+	_POLL_CLOSE_BATCH = 100
+)
+
+// pollRequest is the poll part of a new chirp
+type pollRequest struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+// validatePoll checks a poll's options and closing time, returning the
+// options trimmed of surrounding space
+func validatePoll(poll *pollRequest, now time.Time) ([]string, error) {
+	if len(poll.Options) < _MIN_POLL_OPTIONS || len(poll.Options) > _MAX_POLL_OPTIONS {
+		return nil, fmt.Errorf("a poll must have %d to %d options", _MIN_POLL_OPTIONS, _MAX_POLL_OPTIONS)
+	}
+
+	options := make([]string, len(poll.Options))
+	seen := make(map[string]bool, len(poll.Options))
+	for i, option := range poll.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > _MAX_POLL_OPTION_LENGTH {
+			return nil, fmt.Errorf("poll options must be 1-%d characters", _MAX_POLL_OPTION_LENGTH)
+		}
+
+		key := strings.ToLower(option)
+		if seen[key] {
+			return nil, fmt.Errorf("poll option %q is given more than once", option)
+		}
+		seen[key] = true
+
+		options[i] = option
+	}
+
+	if open := poll.ClosesAt.Sub(now); open < _MIN_POLL_DURATION || open > _MAX_POLL_DURATION {
+		return nil, fmt.Errorf("a poll must close between %v and %v from now", _MIN_POLL_DURATION, _MAX_POLL_DURATION)
+	}
+
+	return options, nil
+}
+
+// createChirpPoll adds a poll with options, in order, to dbChirp
+func createChirpPoll(ctx context.Context, q *database.Queries, dbChirp *database.Chirp, options []string, closesAt time.Time) error {
+	pollParams := database.CreatePollParams{
+		ChirpID:  dbChirp.ID,
+		ClosesAt: closesAt.UTC(),
+	}
+
+	dbPoll, err := q.CreatePoll(ctx, pollParams)
+	if err != nil {
+		return fmt.Errorf("error creating poll: %w", err)
+	}
+
+	for i, option := range options {
+		optionParams := database.CreatePollOptionParams{
+			PollID:   dbPoll.ID,
+			Position: int32(i),
+			Text:     option,
+		}
+
+		if err := q.CreatePollOption(ctx, optionParams); err != nil {
+			return fmt.Errorf("error creating poll option: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// loadPolls returns the polls on chirpIDs keyed by chirp id, as viewerID
+// sees them
+func (cfg *APIConfig) loadPolls(ctx context.Context, viewerID uuid.UUID, chirpIDs []uuid.UUID) (map[uuid.UUID]*APIPoll, error) {
+	dbPolls, err := cfg.DBQueries.GetChirpPolls(ctx, chirpIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting polls: %w", err)
+	}
+
+	polls := make(map[uuid.UUID]*APIPoll, len(dbPolls))
+	if len(dbPolls) == 0 {
+		return polls, nil
+	}
+
+	pollIDs := make([]uuid.UUID, len(dbPolls))
+	for i, dbPoll := range dbPolls {
+		pollIDs[i] = dbPoll.ID
+	}
+
+	rows, err := cfg.DBQueries.GetPollOptions(ctx, pollIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting poll options: %w", err)
+	}
+
+	options := make(map[uuid.UUID][]database.GetPollOptionsRow, len(dbPolls))
+	for _, row := range rows {
+		options[row.PollOption.PollID] = append(options[row.PollOption.PollID], row)
+	}
+
+	votes := map[uuid.UUID]uuid.UUID{}
+	if viewerID != uuid.Nil {
+		votesParams := database.GetPollVotesParams{
+			UserID:  viewerID,
+			PollIds: pollIDs,
+		}
+
+		dbVotes, err := cfg.DBQueries.GetPollVotes(ctx, votesParams)
+		if err != nil {
+			return nil, fmt.Errorf("error getting poll votes: %w", err)
+		}
+		for _, dbVote := range dbVotes {
+			votes[dbVote.PollID] = dbVote.OptionID
+		}
+	}
+
+	now := time.Now()
+	for _, dbPoll := range dbPolls {
+		votedOptionID := uuid.NullUUID{}
+		if optionID, ok := votes[dbPoll.ID]; ok {
+			votedOptionID = uuid.NullUUID{UUID: optionID, Valid: true}
+		}
+
+		apiPoll := NewAPIPoll(&dbPoll, options[dbPoll.ID], votedOptionID, now)
+		polls[dbPoll.ChirpID] = &apiPoll
+	}
+
+	return polls, nil
+}
+
+// HandlerVotePoll POST /api/chirps/{chirpID}/vote
+func (cfg *APIConfig) HandlerVotePoll(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	reqBody := struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}{}
+
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		log.Printf("error decoding request body: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	dbChirp, err := cfg.DBQueries.GetChirp(req.Context(), chirpID)
+	if err != nil || dbChirp.HiddenAt.Valid {
+		err := fmt.Errorf("chirp not found")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if err := cfg.checkNotBlocked(req.Context(), dbChirp.UserID, userID); err != nil {
+		log.Printf("error checking block: %v\n", err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	dbPoll, err := cfg.DBQueries.GetPollByChirpID(req.Context(), chirpID)
+	if err != nil {
+		log.Printf("error getting poll from database: %v\n", err)
+		respondWithError(wr, fmt.Errorf("chirp has no poll: %w", err), http.StatusNotFound)
+		return
+	}
+
+	voteParams := database.CreatePollVoteParams{
+		UserID:   userID,
+		PollID:   dbPoll.ID,
+		OptionID: reqBody.OptionID,
+	}
+
+	voted, err := cfg.DBQueries.CreatePollVote(req.Context(), voteParams)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == _UNIQUE_VIOLATION {
+			err = fmt.Errorf("you have already voted in this poll")
+			log.Println(err)
+			respondWithError(wr, err, http.StatusConflict)
+			return
+		}
+		log.Printf("error creating poll vote: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	if voted == 0 {
+		// either the poll is closed or the option is not one of its own
+		err := fmt.Errorf("option is not part of this poll")
+		code := http.StatusBadRequest
+		if dbPoll.ClosedAt.Valid || !time.Now().Before(dbPoll.ClosesAt) {
+			err = fmt.Errorf("poll is closed")
+			code = http.StatusForbidden
+		}
+		log.Println(err)
+		respondWithError(wr, err, code)
+		return
+	}
+
+	polls, err := cfg.loadPolls(req.Context(), userID, []uuid.UUID{chirpID})
+	if err != nil {
+		log.Printf("error loading poll: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, polls[chirpID], http.StatusOK)
+}
+
+// ClosePolls finalizes every poll past its closing time, freezing its
+// tallies, and returns how many it closed
+func (cfg *APIConfig) ClosePolls(ctx context.Context) (int, error) {
+	closed := 0
+	for {
+		pollIDs, err := cfg.DBQueries.ClosePolls(ctx, _POLL_CLOSE_BATCH)
+		if err != nil {
+			return closed, fmt.Errorf("error closing polls: %w", err)
+		}
+
+		closed += len(pollIDs)
+		if len(pollIDs) < _POLL_CLOSE_BATCH {
+			return closed, nil
+		}
+	}
+}
+
+// RunPollCloser calls ClosePolls every interval until ctx is done. Polls
+// locked by an in-flight vote are skipped and closed on the next pass.
+func (cfg *APIConfig) RunPollCloser(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			closed, err := cfg.ClosePolls(ctx)
+			if err != nil {
+				log.Printf("error running poll closer: %v\n", err)
+				continue
+			}
+			if closed > 0 {
+				log.Printf("closed %d polls\n", closed)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+func TestValidatePoll(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	inADay := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		poll    pollRequest
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Valid",
+			poll: pollRequest{Options: []string{" yes ", "no"}, ClosesAt: inADay},
+			want: []string{"yes", "no"},
+		},
+		{
+			name:    "One option",
+			poll:    pollRequest{Options: []string{"yes"}, ClosesAt: inADay},
+			wantErr: true,
+		},
+		{
+			name:    "Five options",
+			poll:    pollRequest{Options: []string{"a", "b", "c", "d", "e"}, ClosesAt: inADay},
+			wantErr: true,
+		},
+		{
+			name:    "Blank option",
+			poll:    pollRequest{Options: []string{"yes", "  "}, ClosesAt: inADay},
+			wantErr: true,
+		},
+		{
+			name:    "Long option",
+			poll:    pollRequest{Options: []string{"yes", strings.Repeat("n", _MAX_POLL_OPTION_LENGTH+1)}, ClosesAt: inADay},
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate options",
+			poll:    pollRequest{Options: []string{"Yes", "yes"}, ClosesAt: inADay},
+			wantErr: true,
+		},
+		{
+			name:    "Closes too soon",
+			poll:    pollRequest{Options: []string{"yes", "no"}, ClosesAt: now.Add(time.Minute)},
+			wantErr: true,
+		},
+		{
+			name:    "Closes too late",
+			poll:    pollRequest{Options: []string{"yes", "no"}, ClosesAt: now.Add(30 * 24 * time.Hour)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validatePoll(&tt.poll, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validatePoll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("validatePoll() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAPIPoll(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	yesID, noID := uuid.New(), uuid.New()
+
+	options := []database.GetPollOptionsRow{
+		{PollOption: database.PollOption{ID: yesID, Text: "yes", VoteCount: 7}, LiveVotes: 3},
+		{PollOption: database.PollOption{ID: noID, Text: "no", VoteCount: 1}, LiveVotes: 2},
+	}
+
+	t.Run("Open, not voted", func(t *testing.T) {
+		dbPoll := database.Poll{ClosesAt: now.Add(time.Hour)}
+
+		apiPoll := NewAPIPoll(&dbPoll, options, uuid.NullUUID{}, now)
+		if apiPoll.Closed || apiPoll.ResultsVisible || apiPoll.TotalVotes != 5 {
+			t.Fatalf("got closed %v, visible %v, total %d, want open, hidden, 5", apiPoll.Closed, apiPoll.ResultsVisible, apiPoll.TotalVotes)
+		}
+		for _, option := range apiPoll.Options {
+			if option.Votes != nil {
+				t.Errorf("option %q votes shown before voting", option.Text)
+			}
+		}
+	})
+
+	t.Run("Open, voted", func(t *testing.T) {
+		dbPoll := database.Poll{ClosesAt: now.Add(time.Hour)}
+
+		apiPoll := NewAPIPoll(&dbPoll, options, uuid.NullUUID{UUID: noID, Valid: true}, now)
+		if !apiPoll.ResultsVisible || apiPoll.Options[0].Votes == nil || *apiPoll.Options[0].Votes != 3 {
+			t.Fatalf("expected live results after voting, got %+v", apiPoll)
+		}
+	})
+
+	t.Run("Past closing time, not finalized", func(t *testing.T) {
+		dbPoll := database.Poll{ClosesAt: now.Add(-time.Minute)}
+
+		apiPoll := NewAPIPoll(&dbPoll, options, uuid.NullUUID{}, now)
+		if !apiPoll.Closed || !apiPoll.ResultsVisible || *apiPoll.Options[1].Votes != 2 {
+			t.Fatalf("expected closed poll with live results, got %+v", apiPoll)
+		}
+	})
+
+	t.Run("Finalized", func(t *testing.T) {
+		dbPoll := database.Poll{
+			ClosesAt: now.Add(-time.Hour),
+			ClosedAt: sql.NullTime{Time: now.Add(-time.Minute), Valid: true},
+		}
+
+		apiPoll := NewAPIPoll(&dbPoll, options, uuid.NullUUID{}, now)
+		if apiPoll.TotalVotes != 8 || *apiPoll.Options[0].Votes != 7 {
+			t.Fatalf("expected frozen tallies, got total %d, %+v", apiPoll.TotalVotes, apiPoll.Options)
+		}
+	})
+}
@@ -0,0 +1,450 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+const _MAX_REPORT_DETAILS_LENGTH = 1000
+
+// reportReasons are the reasons a user can give for a report. Reports opened
+// by the moderation pipeline use "flagged".
+var reportReasons = map[string]bool{
+	"spam":          true,
+	"harassment":    true,
+	"hate":          true,
+	"violence":      true,
+	"self_harm":     true,
+	"impersonation": true,
+	"other":         true,
+}
+
+// reportStatuses are the states a report moves through, in order
+var reportStatuses = map[string]bool{
+	"open":     true,
+	"claimed":  true,
+	"resolved": true,
+}
+
+// reportResolutions are the ways a moderator can resolve a report
+var reportResolutions = map[string]bool{
+	"hide_chirp":   true,
+	"suspend_user": true,
+	"dismiss":      true,
+}
+
+// decodeReport reads the reason and details of a new report from the request body
+func decodeReport(req *http.Request) (database.CreateReportParams, error) {
+	reqBody := struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}{}
+
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		return database.CreateReportParams{}, fmt.Errorf("error decoding request body: %w", err)
+	}
+
+	if !reportReasons[reqBody.Reason] {
+		return database.CreateReportParams{}, fmt.Errorf("unknown report reason %q", reqBody.Reason)
+	}
+
+	if utf8.RuneCountInString(reqBody.Details) > _MAX_REPORT_DETAILS_LENGTH {
+		return database.CreateReportParams{}, fmt.Errorf("details must be %d characters or less", _MAX_REPORT_DETAILS_LENGTH)
+	}
+
+	return database.CreateReportParams{
+		Reason:  reqBody.Reason,
+		Details: reqBody.Details,
+	}, nil
+}
+
+// HandlerReportChirp POST /api/chirps/{chirpID}/report
+func (cfg *APIConfig) HandlerReportChirp(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("error parsing path {chirpID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	dbChirp, err := cfg.DBQueries.GetChirp(req.Context(), chirpID)
+	if err != nil {
+		log.Printf("error getting chirp from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if dbChirp.UserID == userID {
+		err := fmt.Errorf("you cannot report your own chirp")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	reportParams, err := decodeReport(req)
+	if err != nil {
+		log.Printf("error reading report: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+	reportParams.ReporterID = uuid.NullUUID{UUID: userID, Valid: true}
+	reportParams.ReportedUserID = dbChirp.UserID
+	reportParams.ChirpID = uuid.NullUUID{UUID: dbChirp.ID, Valid: true}
+
+	cfg.createReport(wr, req, reportParams)
+}
+
+// HandlerReportUser POST /api/users/{userID}/report
+func (cfg *APIConfig) HandlerReportUser(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	reportedUserID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if reportedUserID == userID {
+		err := fmt.Errorf("you cannot report yourself")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := cfg.DBQueries.GetUserByID(req.Context(), reportedUserID); err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	reportParams, err := decodeReport(req)
+	if err != nil {
+		log.Printf("error reading report: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+	reportParams.ReporterID = uuid.NullUUID{UUID: userID, Valid: true}
+	reportParams.ReportedUserID = reportedUserID
+
+	cfg.createReport(wr, req, reportParams)
+}
+
+// createReport saves a report and responds with it
+func (cfg *APIConfig) createReport(wr http.ResponseWriter, req *http.Request, reportParams database.CreateReportParams) {
+	dbReport, err := cfg.DBQueries.CreateReport(req.Context(), reportParams)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == _UNIQUE_VIOLATION {
+			err = fmt.Errorf("you have already reported this and it is awaiting review")
+			log.Println(err)
+			respondWithError(wr, err, http.StatusConflict)
+			return
+		}
+		log.Printf("error creating report: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIReport(&dbReport), http.StatusCreated)
+}
+
+// HandlerGetReports GET /admin/reports
+func (cfg *APIConfig) HandlerGetReports(wr http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	status := query.Get("status")
+	if status == "" {
+		status = "open"
+	}
+	if !reportStatuses[status] {
+		err := fmt.Errorf("status must be open, claimed or resolved, got %q", status)
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageParams(query)
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	reportsParams := database.GetReportsPageParams{
+		Status:          status,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	dbReports, err := cfg.DBQueries.GetReportsPage(req.Context(), reportsParams)
+	if err != nil {
+		log.Printf("error retrieving reports from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	dbReports, cursor := nextCursor(dbReports, page.Limit, func(dbReport database.Report) pageCursor {
+		return pageCursor{CreatedAt: dbReport.CreatedAt, ID: dbReport.ID}
+	})
+
+	apiReports := make([]APIReport, len(dbReports))
+	for i, dbReport := range dbReports {
+		apiReports[i] = NewAPIReport(&dbReport)
+	}
+
+	apiReportPage := APIReportPage{
+		Reports:    apiReports,
+		NextCursor: cursor,
+	}
+
+	respondWithJSON(wr, apiReportPage, http.StatusOK)
+}
+
+// HandlerGetReport GET /admin/reports/{reportID}
+func (cfg *APIConfig) HandlerGetReport(wr http.ResponseWriter, req *http.Request) {
+	reportID, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		log.Printf("error parsing path {reportID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	dbReport, err := cfg.DBQueries.GetReport(req.Context(), reportID)
+	if err != nil {
+		log.Printf("error getting report from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	dbActions, err := cfg.DBQueries.GetReportActions(req.Context(), reportID)
+	if err != nil {
+		log.Printf("error getting report actions from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIReportDetail(&dbReport, dbActions), http.StatusOK)
+}
+
+// HandlerClaimReport POST /admin/reports/{reportID}/claim
+func (cfg *APIConfig) HandlerClaimReport(wr http.ResponseWriter, req *http.Request) {
+	moderator, _ := authUserFromContext(req.Context())
+
+	reportID, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		log.Printf("error parsing path {reportID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(req.Context(), nil)
+	if err != nil {
+		log.Printf("error starting transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.DBQueries.WithTx(tx)
+
+	dbReport, err := qtx.GetReportForUpdate(req.Context(), reportID)
+	if err != nil {
+		log.Printf("error getting report from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case dbReport.Status == "resolved":
+		err := fmt.Errorf("report has already been resolved")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusConflict)
+		return
+	case dbReport.ClaimedBy.Valid && dbReport.ClaimedBy.UUID == moderator.ID:
+		respondWithJSON(wr, NewAPIReport(&dbReport), http.StatusOK)
+		return
+	case dbReport.ClaimedBy.Valid:
+		err := fmt.Errorf("report has been claimed by another moderator")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusConflict)
+		return
+	}
+
+	claimParams := database.ClaimReportParams{
+		ID:        reportID,
+		ClaimedBy: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+	}
+
+	dbReport, err = qtx.ClaimReport(req.Context(), claimParams)
+	if err != nil {
+		log.Printf("error claiming report: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	actionParams := database.CreateReportActionParams{
+		ReportID: reportID,
+		AdminID:  uuid.NullUUID{UUID: moderator.ID, Valid: true},
+		Action:   "claim",
+	}
+
+	if _, err := qtx.CreateReportAction(req.Context(), actionParams); err != nil {
+		log.Printf("error recording report action: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("error committing transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIReport(&dbReport), http.StatusOK)
+}
+
+// HandlerResolveReport POST /admin/reports/{reportID}/resolve
+//
+// An unclaimed report is claimed by the resolving moderator on the way.
+func (cfg *APIConfig) HandlerResolveReport(wr http.ResponseWriter, req *http.Request) {
+	moderator, _ := authUserFromContext(req.Context())
+
+	reportID, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		log.Printf("error parsing path {reportID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	reqBody := struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}{}
+
+	if err := json.NewDecoder(req.Body).Decode(&reqBody); err != nil {
+		log.Printf("error decoding request body: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	if !reportResolutions[reqBody.Action] {
+		err := fmt.Errorf("action must be hide_chirp, suspend_user or dismiss, got %q", reqBody.Action)
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(req.Context(), nil)
+	if err != nil {
+		log.Printf("error starting transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.DBQueries.WithTx(tx)
+
+	dbReport, err := qtx.GetReportForUpdate(req.Context(), reportID)
+	if err != nil {
+		log.Printf("error getting report from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if dbReport.Status == "resolved" {
+		err := fmt.Errorf("report has already been resolved")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusConflict)
+		return
+	}
+
+	if dbReport.ClaimedBy.Valid && dbReport.ClaimedBy.UUID != moderator.ID {
+		err := fmt.Errorf("report has been claimed by another moderator")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusConflict)
+		return
+	}
+
+	switch reqBody.Action {
+	case "hide_chirp":
+		if !dbReport.ChirpID.Valid {
+			err := fmt.Errorf("report is not about a chirp")
+			log.Println(err)
+			respondWithError(wr, err, http.StatusBadRequest)
+			return
+		}
+
+		if err := qtx.HideChirp(req.Context(), dbReport.ChirpID.UUID); err != nil {
+			log.Printf("error hiding chirp: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+	case "suspend_user":
+		if err := qtx.SuspendUser(req.Context(), dbReport.ReportedUserID); err != nil {
+			log.Printf("error suspending user: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+
+		if err := qtx.RevokeUserRefreshTokens(req.Context(), dbReport.ReportedUserID); err != nil {
+			log.Printf("error revoking refresh tokens: %v\n", err)
+			respondWithError(wr, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	resolveParams := database.ResolveReportParams{
+		ID:         reportID,
+		AdminID:    moderator.ID,
+		Resolution: reqBody.Action,
+	}
+
+	dbReport, err = qtx.ResolveReport(req.Context(), resolveParams)
+	if err != nil {
+		log.Printf("error resolving report: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	actionParams := database.CreateReportActionParams{
+		ReportID: reportID,
+		AdminID:  uuid.NullUUID{UUID: moderator.ID, Valid: true},
+		Action:   reqBody.Action,
+		Note:     reqBody.Note,
+	}
+
+	if _, err := qtx.CreateReportAction(req.Context(), actionParams); err != nil {
+		log.Printf("error recording report action: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("error committing transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIReport(&dbReport), http.StatusOK)
+}
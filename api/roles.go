@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+const (
+	// RoleAdmin can do anything, including granting and revoking roles
+	RoleAdmin = "admin"
+	// RoleModerator reviews reports and can see hidden chirps
+	RoleModerator = "moderator"
+)
+
+// roles are the roles that can be granted to a user
+var roles = map[string]bool{
+	RoleAdmin:     true,
+	RoleModerator: true,
+}
+
+type authUserContextKey struct{}
+
+// authUser is the caller of a request that went through MiddlewareRequireRoles
+type authUser struct {
+	ID    uuid.UUID
+	Roles []string
+}
+
+// authUserFromContext returns the caller stored by MiddlewareRequireRoles
+func authUserFromContext(ctx context.Context) (authUser, bool) {
+	user, ok := ctx.Value(authUserContextKey{}).(authUser)
+	return user, ok
+}
+
+// MiddlewareRequireRoles only lets requests through from users holding at
+// least one of the given roles, responding 401 to anonymous callers and 403
+// to everyone else. The caller is available to the next handler through
+// authUserFromContext.
+func (cfg *APIConfig) MiddlewareRequireRoles(required ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+			userID, err := cfg.authenticate(req)
+			if err != nil {
+				log.Printf("error authenticating request: %v\n", err)
+				respondWithError(wr, err, http.StatusUnauthorized)
+				return
+			}
+
+			userRoles, err := cfg.DBQueries.GetUserRoles(req.Context(), userID)
+			if err != nil {
+				log.Printf("error getting user roles from database: %v\n", err)
+				respondWithError(wr, err, http.StatusInternalServerError)
+				return
+			}
+
+			if !slices.ContainsFunc(required, func(role string) bool { return slices.Contains(userRoles, role) }) {
+				err := fmt.Errorf("requires one of the roles %v", required)
+				log.Println(err)
+				respondWithError(wr, err, http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(req.Context(), authUserContextKey{}, authUser{ID: userID, Roles: userRoles})
+			next.ServeHTTP(wr, req.WithContext(ctx))
+		})
+	}
+}
+
+// hasAnyRole reports whether userID holds at least one of the given roles,
+// false for anonymous callers
+func (cfg *APIConfig) hasAnyRole(ctx context.Context, userID uuid.UUID, required ...string) (bool, error) {
+	if userID == uuid.Nil {
+		return false, nil
+	}
+
+	userRoles, err := cfg.DBQueries.GetUserRoles(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("error getting user roles from database: %w", err)
+	}
+
+	return slices.ContainsFunc(required, func(role string) bool { return slices.Contains(userRoles, role) }), nil
+}
+
+// HandlerGetUserRoles GET /admin/users/{userID}/roles
+func (cfg *APIConfig) HandlerGetUserRoles(wr http.ResponseWriter, req *http.Request) {
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if _, err := cfg.DBQueries.GetUserByID(req.Context(), userID); err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	userRoles, err := cfg.DBQueries.GetUserRoles(req.Context(), userID)
+	if err != nil {
+		log.Printf("error getting user roles from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, NewAPIUserRoles(userID, userRoles), http.StatusOK)
+}
+
+// HandlerGrantRole PUT /admin/users/{userID}/roles/{role}
+func (cfg *APIConfig) HandlerGrantRole(wr http.ResponseWriter, req *http.Request) {
+	admin, _ := authUserFromContext(req.Context())
+
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	role := req.PathValue("role")
+	if !roles[role] {
+		err := fmt.Errorf("unknown role %q", role)
+		log.Println(err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	if _, err := cfg.DBQueries.GetUserByID(req.Context(), userID); err != nil {
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	grantParams := database.GrantRoleParams{
+		UserID:    userID,
+		Role:      role,
+		GrantedBy: uuid.NullUUID{UUID: admin.ID, Valid: true},
+	}
+
+	if err := cfg.DBQueries.GrantRole(req.Context(), grantParams); err != nil {
+		log.Printf("error granting role: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("user %s granted role %s to user %s", admin.ID, role, userID)
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerRevokeRole DELETE /admin/users/{userID}/roles/{role}
+func (cfg *APIConfig) HandlerRevokeRole(wr http.ResponseWriter, req *http.Request) {
+	admin, _ := authUserFromContext(req.Context())
+
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		log.Printf("error parsing path {userID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	role := req.PathValue("role")
+	if !roles[role] {
+		err := fmt.Errorf("unknown role %q", role)
+		log.Println(err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	// an admin revoking their own admin role could leave nobody able to grant it back
+	if userID == admin.ID && role == RoleAdmin {
+		err := fmt.Errorf("you cannot revoke your own admin role")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	revokeParams := database.RevokeRoleParams{
+		UserID: userID,
+		Role:   role,
+	}
+
+	if err := cfg.DBQueries.RevokeRole(req.Context(), revokeParams); err != nil {
+		log.Printf("error revoking role: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("user %s revoked role %s from user %s", admin.ID, role, userID)
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+const (
+	_MAX_SEARCH_QUERY_LENGTH = 256
+
+	// SearchChirps marks matches with these private use characters, which it
+	// strips from chirp bodies first, so highlightHTML can escape the body
+	// before swapping them for tags
+	_HIGHLIGHT_START = "\ue000"
+	_HIGHLIGHT_STOP  = "\ue001"
+)
+
+// highlightHTML escapes a chirp body highlighted by SearchChirps and wraps
+// its matches in <mark></mark>, so it is safe to render as HTML
+func highlightHTML(highlight string) string {
+	highlight = html.EscapeString(highlight)
+	highlight = strings.ReplaceAll(highlight, _HIGHLIGHT_START, "<mark>")
+	return strings.ReplaceAll(highlight, _HIGHLIGHT_STOP, "</mark>")
+}
+
+// buildTSQuery turns a user's search into a PostgreSQL to_tsquery
+// expression. Terms are ANDed together, "quoted words" must appear next to
+// each other as a phrase and a trailing * makes a term match as a prefix
+// ("chirp*" finds chirpy). Anything other than letters and digits splits
+// terms, so no tsquery operators can be smuggled in. It returns "" when q
+// has nothing to search for.
+func buildTSQuery(q string) string {
+	terms := []string{}
+
+	// odd fields are inside double quotes
+	for i, part := range strings.Split(q, `"`) {
+		if i%2 == 1 {
+			if words := searchWords(part); len(words) > 0 {
+				terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			words := searchWords(field)
+			if len(words) == 0 {
+				continue
+			}
+			if strings.HasSuffix(field, "*") {
+				words[len(words)-1] += ":*"
+			}
+			terms = append(terms, words...)
+		}
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// searchWords splits s into its runs of letters and digits
+func searchWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// HandlerSearchChirps GET /api/search/chirps
+func (cfg *APIConfig) HandlerSearchChirps(wr http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.optionalAuthenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	query := req.URL.Query()
+
+	q := query.Get("q")
+	if len(q) > _MAX_SEARCH_QUERY_LENGTH {
+		err := fmt.Errorf("q must be %d characters or less", _MAX_SEARCH_QUERY_LENGTH)
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	tsQuery := buildTSQuery(q)
+	if tsQuery == "" {
+		err := fmt.Errorf("q must contain at least one word")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	authorID, err := parseOptionalUUID(query, "author_id")
+	if err != nil {
+		log.Printf("error parsing author_id: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageParams(query)
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	searchParams := database.SearchChirpsParams{
+		Query:           tsQuery,
+		AuthorID:        authorID,
+		ViewerID:        viewerID,
+		CursorRank:      page.CursorRank,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	rows, err := cfg.DBQueries.SearchChirps(req.Context(), searchParams)
+	if err != nil {
+		log.Printf("error searching chirps: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	rows, cursor := nextCursor(rows, page.Limit, func(row database.SearchChirpsRow) pageCursor {
+		return pageCursor{Rank: row.Rank, CreatedAt: row.Chirp.CreatedAt, ID: row.Chirp.ID}
+	})
+
+	apiChirps := make([]APIChirp, len(rows))
+	for i, row := range rows {
+		apiChirps[i] = NewAPIChirp(&row.Chirp)
+	}
+
+	if err := cfg.loadChirpDetails(req.Context(), viewerID, apiChirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiSearchPage := APISearchPage{
+		Results:    make([]APISearchResult, len(rows)),
+		NextCursor: cursor,
+	}
+	for i, row := range rows {
+		apiSearchPage.Results[i] = APISearchResult{
+			Chirp:     apiChirps[i],
+			Highlight: highlightHTML(row.Highlight),
+			Rank:      row.Rank,
+		}
+	}
+
+	respondWithJSON(wr, apiSearchPage, http.StatusOK)
+}
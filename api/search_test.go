@@ -0,0 +1,82 @@
+package api
+
+import "testing"
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		q    string
+		want string
+	}{
+		{
+			name: "Words",
+			q:    "hello world",
+			want: "hello & world",
+		},
+		{
+			name: "Phrase",
+			q:    `"hello world" again`,
+			want: "(hello <-> world) & again",
+		},
+		{
+			name: "Prefix",
+			q:    "chirp*",
+			want: "chirp:*",
+		},
+		{
+			name: "Operators stripped",
+			q:    "a|b & !c:* (d)",
+			want: "a & b & c:* & d",
+		},
+		{
+			name: "Unterminated quote",
+			q:    `foo "bar baz`,
+			want: "foo & (bar <-> baz)",
+		},
+		{
+			name: "Unicode",
+			q:    "zoë café",
+			want: "zoë & café",
+		},
+		{
+			name: "Nothing to search",
+			q:    ` "" * !! `,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildTSQuery(tt.q); got != tt.want {
+				t.Errorf("buildTSQuery(%q) = %q, want %q", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		highlight string
+		want      string
+	}{
+		{
+			name:      "Match",
+			highlight: "hello \ue000world\ue001",
+			want:      "hello <mark>world</mark>",
+		},
+		{
+			name:      "Markup in the body",
+			highlight: "<img src=x onerror=\"alert(1)\"> \ue000chirp\ue001 <mark>",
+			want:      `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>chirp</mark> &lt;mark&gt;`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightHTML(tt.highlight); got != tt.want {
+				t.Errorf("highlightHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
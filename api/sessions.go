@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+const (
+	_MAX_USER_AGENT_LENGTH   = 512
+	_MAX_DEVICE_LABEL_LENGTH = 50
+)
+
+// userAgentBrowsers and userAgentSystems name the browser and operating
+// system a User-Agent header is from. They are checked in order, since
+// most browsers also claim to be the ones listed after them.
+var (
+	userAgentBrowsers = []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	}
+	userAgentSystems = []struct{ token, name string }{
+		{"Windows", "Windows"},
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// deviceLabel names the device a session was started from by its
+// User-Agent header, such as "Firefox on Windows"
+func deviceLabel(userAgent string) string {
+	browser, system := "", ""
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range userAgentSystems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "Unknown device"
+	}
+}
+
+// clientIP is the address a request came from. Forwarding headers are not
+// trusted since anyone can send them.
+func clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// truncateRunes cuts s down to at most n runes
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// newSessionParams records where a login comes from. The client can name
+// the device itself, otherwise it is named after the User-Agent header.
+func newSessionParams(req *http.Request, userID uuid.UUID, label string) database.CreateSessionParams {
+	userAgent := truncateRunes(req.UserAgent(), _MAX_USER_AGENT_LENGTH)
+
+	label = truncateRunes(strings.TrimSpace(label), _MAX_DEVICE_LABEL_LENGTH)
+	if label == "" {
+		label = deviceLabel(userAgent)
+	}
+
+	return database.CreateSessionParams{
+		UserID:      userID,
+		UserAgent:   userAgent,
+		IpAddress:   clientIP(req),
+		DeviceLabel: label,
+	}
+}
+
+// authenticateSession is authenticate that also returns the session the
+// access token was issued for. Tokens stop working as soon as their session
+// is revoked, so this costs a lookup on every request, made once by
+// MiddlewareClaims when the request went through it.
+func (cfg *APIConfig) authenticateSession(req *http.Request) (uuid.UUID, uuid.UUID, error) {
+	token, ok := accessTokenFromContext(req.Context())
+	if !ok {
+		claims, err := cfg.verifyAccessToken(req)
+		token = accessToken{claims: claims, err: err}
+	}
+	if token.err != nil {
+		return uuid.Nil, uuid.Nil, token.err
+	}
+
+	return claimIDs(token.claims)
+}
+
+// checkSessionActive returns an error when sessionID of userID has been
+// revoked or has expired
+func (cfg *APIConfig) checkSessionActive(ctx context.Context, userID, sessionID uuid.UUID) error {
+	activeParams := database.IsSessionActiveParams{
+		SessionID: sessionID,
+		UserID:    userID,
+	}
+
+	active, err := cfg.DBQueries.IsSessionActive(ctx, activeParams)
+	if err != nil {
+		return fmt.Errorf("error checking session: %w", err)
+	}
+
+	if !active {
+		return fmt.Errorf("session has been revoked")
+	}
+
+	return nil
+}
+
+// HandlerGetSessions GET /api/sessions
+func (cfg *APIConfig) HandlerGetSessions(wr http.ResponseWriter, req *http.Request) {
+	userID, sessionID, err := cfg.authenticateSession(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	dbSessions, err := cfg.DBQueries.GetActiveSessions(req.Context(), userID)
+	if err != nil {
+		log.Printf("error retrieving sessions from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiSessions := make([]APISession, len(dbSessions))
+	for i, dbSession := range dbSessions {
+		apiSessions[i] = NewAPISession(&dbSession, sessionID)
+	}
+
+	respondWithJSON(wr, apiSessions, http.StatusOK)
+}
+
+// HandlerRevokeSession DELETE /api/sessions/{sessionID}
+//
+// Logs the session out: its refresh token and access tokens stop working.
+func (cfg *APIConfig) HandlerRevokeSession(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := uuid.Parse(req.PathValue("sessionID"))
+	if err != nil {
+		log.Printf("error parsing path {sessionID}: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	revokeParams := database.RevokeSessionParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	}
+
+	revoked, err := cfg.DBQueries.RevokeSession(req.Context(), revokeParams)
+	if err != nil {
+		log.Printf("error revoking session: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	if revoked == 0 {
+		err := fmt.Errorf("session not found")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
+
+// HandlerRevokeAllSessions POST /api/sessions/revoke-all
+//
+// Logs the caller out everywhere, this session included.
+func (cfg *APIConfig) HandlerRevokeAllSessions(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	if err := cfg.DBQueries.RevokeUserRefreshTokens(req.Context(), userID); err != nil {
+		log.Printf("error revoking refresh tokens: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, struct{}{}, http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDeviceLabel(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{
+			name:      "Firefox on Windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0",
+			want:      "Firefox on Windows",
+		},
+		{
+			name:      "Chrome on Android",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+			want:      "Chrome on Android",
+		},
+		{
+			name:      "Safari on iOS",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+			want:      "Safari on iOS",
+		},
+		{
+			name:      "Edge on macOS",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0",
+			want:      "Edge on macOS",
+		},
+		{
+			name:      "curl",
+			userAgent: "curl/8.5.0",
+			want:      "curl",
+		},
+		{
+			name:      "Empty",
+			userAgent: "",
+			want:      "Unknown device",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deviceLabel(tt.userAgent); got != tt.want {
+				t.Errorf("deviceLabel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSessionParams(t *testing.T) {
+	userID := uuid.New()
+
+	req := httptest.NewRequest("POST", "/api/login", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "curl/8.5.0")
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	params := newSessionParams(req, userID, "")
+	if params.UserID != userID || params.IpAddress != "203.0.113.7" || params.DeviceLabel != "curl" {
+		t.Errorf("newSessionParams() = %+v, want ip 203.0.113.7 and label curl", params)
+	}
+
+	params = newSessionParams(req, userID, "  "+strings.Repeat("x", _MAX_DEVICE_LABEL_LENGTH+10))
+	if params.DeviceLabel != strings.Repeat("x", _MAX_DEVICE_LABEL_LENGTH) {
+		t.Errorf("newSessionParams() label = %q, want it cut to %d characters", params.DeviceLabel, _MAX_DEVICE_LABEL_LENGTH)
+	}
+}
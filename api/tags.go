@@ -0,0 +1,89 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/mmycroft/boot-dev-chirpy/database"
+)
+
+const _DEFAULT_TRENDING_LIMIT = 10
+
+// HandlerGetTagChirps GET /api/tags/{tag}/chirps
+func (cfg *APIConfig) HandlerGetTagChirps(wr http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.optionalAuthenticate(req)
+	if err != nil {
+		log.Printf("error authenticating request: %v\n", err)
+		respondWithError(wr, err, http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageParams(req.URL.Query())
+	if err != nil {
+		log.Printf("error parsing page parameters: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	tag, err := cfg.DBQueries.GetTagByName(req.Context(), normalizeTag(req.PathValue("tag")))
+	if err != nil {
+		log.Printf("error getting tag from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
+		return
+	}
+
+	tagParams := database.GetChirpsByTagParams{
+		TagID:           tag.ID,
+		ViewerID:        viewerID,
+		CursorCreatedAt: page.CursorCreatedAt,
+		CursorID:        page.CursorID,
+		Limit:           page.Limit + 1,
+	}
+
+	dbChirps, err := cfg.DBQueries.GetChirpsByTag(req.Context(), tagParams)
+	if err != nil {
+		log.Printf("error retrieving tag chirps from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiChirpPage := NewAPIChirpPage(dbChirps, page.Limit)
+
+	if err := cfg.loadChirpDetails(req.Context(), viewerID, apiChirpPage.Chirps); err != nil {
+		log.Printf("error loading chirp details: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(wr, apiChirpPage, http.StatusOK)
+}
+
+// HandlerGetTrendingTags GET /api/tags/trending
+func (cfg *APIConfig) HandlerGetTrendingTags(wr http.ResponseWriter, req *http.Request) {
+	limit, err := parseLimit(req.URL.Query(), _DEFAULT_TRENDING_LIMIT)
+	if err != nil {
+		log.Printf("error parsing limit: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
+	trendingParams := database.GetTrendingTagsParams{
+		HalfLifeSeconds: cfg.TrendingHalfLife.Seconds(),
+		WindowSeconds:   cfg.TrendingWindow.Seconds(),
+		Limit:           limit,
+	}
+
+	rows, err := cfg.DBQueries.GetTrendingTags(req.Context(), trendingParams)
+	if err != nil {
+		log.Printf("error retrieving trending tags from database: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiTrendingTags := make([]APITrendingTag, len(rows))
+	for i, row := range rows {
+		apiTrendingTags[i] = NewAPITrendingTag(&row)
+	}
+
+	respondWithJSON(wr, apiTrendingTags, http.StatusOK)
+}
@@ -1,59 +1,661 @@
 package api
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/mmycroft/boot-dev-chirpy/database"
+	"github.com/mmycroft/boot-dev-chirpy/storage"
 )
 
 type APIChirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID            uuid.UUID       `json:"id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	Body          string          `json:"body"`
+	UserID        uuid.UUID       `json:"user_id"`
+	InReplyToID   uuid.NullUUID   `json:"in_reply_to_id"`
+	ThreadID      uuid.UUID       `json:"thread_id"`
+	QuotedChirpID uuid.NullUUID   `json:"quoted_chirp_id"`
+	IsRechirp     bool            `json:"is_rechirp"`
+	Hidden        bool            `json:"hidden"`
+	Pinned        bool            `json:"pinned,omitempty"`
+	QuotedChirp   *APIQuotedChirp `json:"quoted_chirp,omitempty"`
+	ReplyCount    int64           `json:"reply_count"`
+	LikeCount     int64           `json:"like_count"`
+	LikedByMe     bool            `json:"liked_by_me"`
+	Mentions      []APIMention    `json:"mentions"`
+	Media         []APIMedia      `json:"media"`
+	Poll          *APIPoll        `json:"poll,omitempty"`
 }
 
-type APIToken struct {
-	Token string `json:"token"`
+// APIPoll is a poll on a chirp as one viewer sees it. TotalVotes is always
+// live, the votes per option are only filled in once the viewer has voted
+// or the poll has closed.
+type APIPoll struct {
+	ID             uuid.UUID       `json:"id"`
+	ClosesAt       time.Time       `json:"closes_at"`
+	Closed         bool            `json:"closed"`
+	TotalVotes     int64           `json:"total_votes"`
+	ResultsVisible bool            `json:"results_visible"`
+	VotedOptionID  uuid.NullUUID   `json:"voted_option_id"`
+	Options        []APIPollOption `json:"options"`
 }
 
-type APIUser struct {
+type APIPollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Text  string    `json:"text"`
+	Votes *int64    `json:"votes"`
+}
+
+// APIMedia is an uploaded image, on its own or attached to a chirp
+type APIMedia struct {
 	ID           uuid.UUID `json:"id"`
 	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Email        string    `json:"email"`
-	IsChirpyRed  bool      `json:"is_chirpy_red"`
-	Token        string    `json:"token"`
-	RefreshToken string    `json:"refresh_token"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	AltText      string    `json:"alt_text"`
+}
+
+// APIMention is an @handle in a chirp body that resolved to a user. Start
+// and End are code point offsets into the body, End exclusive.
+type APIMention struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+// APIQuotedChirp is the chirp embedded in a rechirp or quote chirp, or a
+// tombstone (Chirp nil) with Deleted set if the original has been deleted,
+// or Unavailable set if it was hidden by a moderator or its author is
+// blocked or muted by the viewer
+type APIQuotedChirp struct {
+	ID          uuid.UUID `json:"id"`
+	Deleted     bool      `json:"deleted"`
+	Unavailable bool      `json:"unavailable"`
+	Chirp       *APIChirp `json:"chirp,omitempty"`
+}
+
+type APIChirpPage struct {
+	Chirps     []APIChirp `json:"chirps"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// APIThreadNode is one chirp in a conversation tree. Chirps that have been
+// deleted (Deleted set), or that the viewer may not see (Unavailable set),
+// are kept as tombstones with Chirp nil so their replies still hang off the
+// right place.
+type APIThreadNode struct {
+	ID          uuid.UUID       `json:"id"`
+	Deleted     bool            `json:"deleted"`
+	Unavailable bool            `json:"unavailable"`
+	Chirp       *APIChirp       `json:"chirp,omitempty"`
+	Replies     []APIThreadNode `json:"replies"`
+}
+
+// APISearchResult is a chirp matching a search. Highlight is the chirp body
+// as escaped HTML with the matched terms wrapped in <mark></mark>.
+type APISearchResult struct {
+	Chirp     APIChirp `json:"chirp"`
+	Highlight string   `json:"highlight"`
+	Rank      float64  `json:"rank"`
+}
+
+type APISearchPage struct {
+	Results    []APISearchResult `json:"results"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// APIBookmark is a chirp the caller saved. Chirps hidden by a moderator, and
+// chirps by users the caller blocked or muted, are kept as tombstones
+// (Hidden set, Chirp nil) so the bookmark can still be removed.
+type APIBookmark struct {
+	ChirpID      uuid.UUID     `json:"chirp_id"`
+	CollectionID uuid.NullUUID `json:"collection_id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Hidden       bool          `json:"hidden"`
+	Chirp        *APIChirp     `json:"chirp,omitempty"`
+}
+
+type APIBookmarkPage struct {
+	Bookmarks  []APIBookmark `json:"bookmarks"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// APIDraft is a chirp saved to be finished or published later. PublishAt is
+// when the scheduler will publish it; PublishError says why it could not,
+// which also unschedules it.
+type APIDraft struct {
+	ID           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Body         string        `json:"body"`
+	InReplyTo    uuid.NullUUID `json:"in_reply_to"`
+	RechirpOf    uuid.NullUUID `json:"rechirp_of"`
+	QuoteOf      uuid.NullUUID `json:"quote_of"`
+	MediaIDs     []uuid.UUID   `json:"media_ids"`
+	Poll         *pollRequest  `json:"poll,omitempty"`
+	PublishAt    *time.Time    `json:"publish_at"`
+	PublishError string        `json:"publish_error,omitempty"`
+}
+
+type APIDraftPage struct {
+	Drafts     []APIDraft `json:"drafts"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+type APIBookmarkCollection struct {
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Name          string    `json:"name"`
+	BookmarkCount int64     `json:"bookmark_count"`
+}
+
+// APIChirpRevision is one version of a chirp's body. The current version is
+// last and has no ReplacedAt.
+type APIChirpRevision struct {
+	Body       string     `json:"body"`
+	AuthoredAt time.Time  `json:"authored_at"`
+	ReplacedAt *time.Time `json:"replaced_at"`
+}
+
+type APIChirpHistory struct {
+	ChirpID   uuid.UUID          `json:"chirp_id"`
+	Revisions []APIChirpRevision `json:"revisions"`
+}
+
+// APITrendingTag is a tag with its time-decayed usage score, where each use
+// counts for less the older it is
+type APITrendingTag struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+	Uses  int64   `json:"uses"`
+}
+
+// APIReport is a user's or the moderation pipeline's report of a chirp or
+// account. ReporterID is null for chirps flagged by moderation.
+type APIReport struct {
+	ID             uuid.UUID     `json:"id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	ReporterID     uuid.NullUUID `json:"reporter_id"`
+	ReportedUserID uuid.UUID     `json:"reported_user_id"`
+	ChirpID        uuid.NullUUID `json:"chirp_id"`
+	Reason         string        `json:"reason"`
+	Details        string        `json:"details"`
+	Status         string        `json:"status"`
+	ClaimedBy      uuid.NullUUID `json:"claimed_by"`
+	ClaimedAt      *time.Time    `json:"claimed_at"`
+	Resolution     string        `json:"resolution,omitempty"`
+	ResolvedAt     *time.Time    `json:"resolved_at"`
+}
+
+type APIReportPage struct {
+	Reports    []APIReport `json:"reports"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// APIReportAction is a moderator's claim or resolution of a report
+type APIReportAction struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	AdminID   uuid.NullUUID `json:"admin_id"`
+	Action    string        `json:"action"`
+	Note      string        `json:"note"`
+}
+
+// APIReportDetail is a report with everything done about it, oldest first
+type APIReportDetail struct {
+	APIReport
+	Actions []APIReportAction `json:"actions"`
 }
 
-func NewAPIUser(dbUser *database.User, token, refreshToken string) APIUser {
+type APIUserRoles struct {
+	UserID uuid.UUID `json:"user_id"`
+	Roles  []string  `json:"roles"`
+}
+
+type APIToken struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// APISession is a login that is still active. Current marks the session
+// of the access token the list was asked for with.
+type APISession struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	DeviceLabel string    `json:"device_label"`
+	Current     bool      `json:"current"`
+}
+
+type APIUser struct {
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Email         string        `json:"email"`
+	Handle        string        `json:"handle"`
+	DisplayName   string        `json:"display_name"`
+	Bio           string        `json:"bio"`
+	AvatarURL     string        `json:"avatar_url"`
+	Avatars       APIAvatarURLs `json:"avatars"`
+	IsChirpyRed   bool          `json:"is_chirpy_red"`
+	PinnedChirpID uuid.NullUUID `json:"pinned_chirp_id"`
+	Token         string        `json:"token"`
+	RefreshToken  string        `json:"refresh_token"`
+}
+
+// APIProfile is the public view of a user, safe to show to anyone. It must
+// never carry the user's email or credentials.
+type APIProfile struct {
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	Handle        string        `json:"handle"`
+	DisplayName   string        `json:"display_name"`
+	Bio           string        `json:"bio"`
+	AvatarURL     string        `json:"avatar_url"`
+	Avatars       APIAvatarURLs `json:"avatars"`
+	IsChirpyRed   bool          `json:"is_chirpy_red"`
+	PinnedChirpID uuid.NullUUID `json:"pinned_chirp_id"`
+}
+
+// APIAvatarURLs is a user's avatar at each square size it comes in. It is
+// their uploaded avatar, the avatar url they picked or their identicon.
+type APIAvatarURLs struct {
+	Small  string `json:"48"`
+	Medium string `json:"128"`
+	Large  string `json:"400"`
+}
+
+type APIProfilePage struct {
+	Users      []APIProfile `json:"users"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+func NewAPIUser(dbUser *database.User, store storage.Storage, token, refreshToken string) APIUser {
+	avatars := NewAPIAvatarURLs(dbUser, store)
+
 	return APIUser{
-		ID:           dbUser.ID,
-		CreatedAt:    dbUser.CreatedAt,
-		UpdatedAt:    dbUser.UpdatedAt,
-		Email:        dbUser.Email,
-		IsChirpyRed:  dbUser.IsChirpyRed,
+		ID:            dbUser.ID,
+		CreatedAt:     dbUser.CreatedAt,
+		UpdatedAt:     dbUser.UpdatedAt,
+		Email:         dbUser.Email,
+		Handle:        dbUser.Handle,
+		DisplayName:   dbUser.DisplayName,
+		Bio:           dbUser.Bio,
+		AvatarURL:     avatars.Medium,
+		Avatars:       avatars,
+		IsChirpyRed:   dbUser.IsChirpyRed,
+		PinnedChirpID: dbUser.PinnedChirpID,
+		Token:         token,
+		RefreshToken:  refreshToken,
+	}
+}
+
+func NewAPIProfile(dbUser *database.User, store storage.Storage) APIProfile {
+	avatars := NewAPIAvatarURLs(dbUser, store)
+
+	return APIProfile{
+		ID:            dbUser.ID,
+		CreatedAt:     dbUser.CreatedAt,
+		Handle:        dbUser.Handle,
+		DisplayName:   dbUser.DisplayName,
+		Bio:           dbUser.Bio,
+		AvatarURL:     avatars.Medium,
+		Avatars:       avatars,
+		IsChirpyRed:   dbUser.IsChirpyRed,
+		PinnedChirpID: dbUser.PinnedChirpID,
+	}
+}
+
+func NewAPIAvatarURLs(dbUser *database.User, store storage.Storage) APIAvatarURLs {
+	urlFor := func(size int) string {
+		switch {
+		case dbUser.AvatarKey != "":
+			return store.URL(avatarKey(dbUser.AvatarKey, size))
+		case dbUser.AvatarUrl != "":
+			return dbUser.AvatarUrl
+		default:
+			return identiconURL(dbUser.ID, size)
+		}
+	}
+
+	return APIAvatarURLs{
+		Small:  urlFor(_AVATAR_SMALL),
+		Medium: urlFor(_AVATAR_MEDIUM),
+		Large:  urlFor(_AVATAR_LARGE),
+	}
+}
+
+func NewAPIReport(dbReport *database.Report) APIReport {
+	apiReport := APIReport{
+		ID:             dbReport.ID,
+		CreatedAt:      dbReport.CreatedAt,
+		UpdatedAt:      dbReport.UpdatedAt,
+		ReporterID:     dbReport.ReporterID,
+		ReportedUserID: dbReport.ReportedUserID,
+		ChirpID:        dbReport.ChirpID,
+		Reason:         dbReport.Reason,
+		Details:        dbReport.Details,
+		Status:         dbReport.Status,
+		ClaimedBy:      dbReport.ClaimedBy,
+		Resolution:     dbReport.Resolution.String,
+	}
+
+	if dbReport.ClaimedAt.Valid {
+		apiReport.ClaimedAt = &dbReport.ClaimedAt.Time
+	}
+	if dbReport.ResolvedAt.Valid {
+		apiReport.ResolvedAt = &dbReport.ResolvedAt.Time
+	}
+
+	return apiReport
+}
+
+func NewAPIReportDetail(dbReport *database.Report, dbActions []database.ReportAction) APIReportDetail {
+	apiActions := make([]APIReportAction, len(dbActions))
+	for i, dbAction := range dbActions {
+		apiActions[i] = APIReportAction{
+			ID:        dbAction.ID,
+			CreatedAt: dbAction.CreatedAt,
+			AdminID:   dbAction.AdminID,
+			Action:    dbAction.Action,
+			Note:      dbAction.Note,
+		}
+	}
+
+	return APIReportDetail{
+		APIReport: NewAPIReport(dbReport),
+		Actions:   apiActions,
+	}
+}
+
+func NewAPIUserRoles(userID uuid.UUID, userRoles []string) APIUserRoles {
+	if userRoles == nil {
+		userRoles = []string{}
+	}
+
+	return APIUserRoles{
+		UserID: userID,
+		Roles:  userRoles,
+	}
+}
+
+func NewAPIToken(token, refreshToken string) APIToken {
+	return APIToken{
 		Token:        token,
 		RefreshToken: refreshToken,
 	}
 }
 
-func NewAPIToken(token string) APIToken {
-	return APIToken{
-		Token: token,
+func NewAPISession(dbSession *database.Session, currentSessionID uuid.UUID) APISession {
+	return APISession{
+		ID:          dbSession.ID,
+		CreatedAt:   dbSession.CreatedAt,
+		LastUsedAt:  dbSession.UpdatedAt,
+		UserAgent:   dbSession.UserAgent,
+		IPAddress:   dbSession.IpAddress,
+		DeviceLabel: dbSession.DeviceLabel,
+		Current:     dbSession.ID == currentSessionID,
 	}
 }
 
 func NewAPIChirp(dbChirp *database.Chirp) APIChirp {
+	threadID := dbChirp.ID
+	if dbChirp.ThreadID.Valid {
+		threadID = dbChirp.ThreadID.UUID
+	}
+
 	return APIChirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+		ID:            dbChirp.ID,
+		CreatedAt:     dbChirp.CreatedAt,
+		UpdatedAt:     dbChirp.UpdatedAt,
+		Body:          dbChirp.Body,
+		UserID:        dbChirp.UserID,
+		InReplyToID:   dbChirp.InReplyToID,
+		ThreadID:      threadID,
+		QuotedChirpID: dbChirp.QuotedChirpID,
+		IsRechirp:     dbChirp.IsRechirp,
+		Hidden:        dbChirp.HiddenAt.Valid,
+		Mentions:      []APIMention{},
+		Media:         []APIMedia{},
+	}
+}
+
+// NewAPIPoll builds the poll as seen by a viewer who voted for
+// votedOptionID, if anyone. Closed polls report the tallies frozen by the
+// closer, open ones the live counts.
+func NewAPIPoll(dbPoll *database.Poll, options []database.GetPollOptionsRow, votedOptionID uuid.NullUUID, now time.Time) APIPoll {
+	closed := dbPoll.ClosedAt.Valid || !now.Before(dbPoll.ClosesAt)
+
+	apiPoll := APIPoll{
+		ID:             dbPoll.ID,
+		ClosesAt:       dbPoll.ClosesAt,
+		Closed:         closed,
+		ResultsVisible: closed || votedOptionID.Valid,
+		VotedOptionID:  votedOptionID,
+		Options:        make([]APIPollOption, len(options)),
+	}
+
+	for i, option := range options {
+		votes := option.LiveVotes
+		if dbPoll.ClosedAt.Valid {
+			votes = option.PollOption.VoteCount
+		}
+		apiPoll.TotalVotes += votes
+
+		apiPoll.Options[i] = APIPollOption{
+			ID:   option.PollOption.ID,
+			Text: option.PollOption.Text,
+		}
+		if apiPoll.ResultsVisible {
+			apiPoll.Options[i].Votes = &votes
+		}
+	}
+
+	return apiPoll
+}
+
+func NewAPIMedia(dbMedia *database.MediaAttachment, store storage.Storage) APIMedia {
+	return APIMedia{
+		ID:           dbMedia.ID,
+		CreatedAt:    dbMedia.CreatedAt,
+		URL:          store.URL(dbMedia.StorageKey),
+		ThumbnailURL: store.URL(dbMedia.ThumbnailKey),
+		ContentType:  dbMedia.ContentType,
+		Width:        dbMedia.Width,
+		Height:       dbMedia.Height,
+		AltText:      dbMedia.AltText,
+	}
+}
+
+func NewAPIMention(body string, dbMention *database.ChirpMention) APIMention {
+	handle := ""
+	if runes := []rune(body); int(dbMention.EndOffset) <= len(runes) && dbMention.StartOffset < dbMention.EndOffset {
+		handle = strings.TrimPrefix(string(runes[dbMention.StartOffset:dbMention.EndOffset]), "@")
+	}
+
+	return APIMention{
+		UserID: dbMention.UserID,
+		Handle: handle,
+		Start:  dbMention.StartOffset,
+		End:    dbMention.EndOffset,
+	}
+}
+
+// NewAPIQuotedChirp embeds the chirp with id quotedID from quoted, falling
+// back to a tombstone when it is unavailable or not there at all
+func NewAPIQuotedChirp(quotedID uuid.UUID, quoted map[uuid.UUID]APIChirp, unavailable map[uuid.UUID]bool) *APIQuotedChirp {
+	if unavailable[quotedID] {
+		return &APIQuotedChirp{ID: quotedID, Unavailable: true}
+	}
+
+	apiChirp, ok := quoted[quotedID]
+	if !ok {
+		return &APIQuotedChirp{ID: quotedID, Deleted: true}
+	}
+
+	return &APIQuotedChirp{ID: quotedID, Chirp: &apiChirp}
+}
+
+func NewAPIBookmarkCollection(dbCollection *database.BookmarkCollection, bookmarkCount int64) APIBookmarkCollection {
+	return APIBookmarkCollection{
+		ID:            dbCollection.ID,
+		CreatedAt:     dbCollection.CreatedAt,
+		UpdatedAt:     dbCollection.UpdatedAt,
+		Name:          dbCollection.Name,
+		BookmarkCount: bookmarkCount,
+	}
+}
+
+func NewAPIDraft(dbDraft *database.Draft) APIDraft {
+	chirpReq := draftChirpRequest(dbDraft)
+
+	apiDraft := APIDraft{
+		ID:           dbDraft.ID,
+		CreatedAt:    dbDraft.CreatedAt,
+		UpdatedAt:    dbDraft.UpdatedAt,
+		Body:         chirpReq.Body,
+		InReplyTo:    chirpReq.InReplyTo,
+		RechirpOf:    chirpReq.RechirpOf,
+		QuoteOf:      chirpReq.QuoteOf,
+		MediaIDs:     chirpReq.MediaIDs,
+		Poll:         chirpReq.Poll,
+		PublishError: dbDraft.PublishError,
+	}
+
+	if dbDraft.PublishAt.Valid {
+		apiDraft.PublishAt = &dbDraft.PublishAt.Time
+	}
+
+	return apiDraft
+}
+
+func NewAPIDraftPage(dbDrafts []database.Draft, limit int32) APIDraftPage {
+	dbDrafts, cursor := nextCursor(dbDrafts, limit, func(dbDraft database.Draft) pageCursor {
+		return pageCursor{CreatedAt: dbDraft.CreatedAt, ID: dbDraft.ID}
+	})
+
+	apiDrafts := make([]APIDraft, len(dbDrafts))
+	for i, dbDraft := range dbDrafts {
+		apiDrafts[i] = NewAPIDraft(&dbDraft)
+	}
+
+	return APIDraftPage{
+		Drafts:     apiDrafts,
+		NextCursor: cursor,
+	}
+}
+
+func NewAPIChirpPage(dbChirps []database.Chirp, limit int32) APIChirpPage {
+	dbChirps, cursor := nextCursor(dbChirps, limit, func(dbChirp database.Chirp) pageCursor {
+		return pageCursor{CreatedAt: dbChirp.CreatedAt, ID: dbChirp.ID}
+	})
+
+	apiChirps := make([]APIChirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		apiChirps[i] = NewAPIChirp(&dbChirp)
+	}
+
+	return APIChirpPage{
+		Chirps:     apiChirps,
+		NextCursor: cursor,
+	}
+}
+
+// NewAPIThread builds the conversation tree rooted at rootID from apiChirps,
+// which must be ordered oldest first. Chirps in unavailable only need their
+// ID and InReplyToID and are rendered as unavailable tombstones. Parents
+// missing from apiChirps have been deleted and are rendered as deleted
+// tombstones; since a deleted chirp's own parent is unknown, those other
+// than the root hang off the root.
+func NewAPIThread(rootID uuid.UUID, apiChirps []APIChirp, unavailable map[uuid.UUID]bool) APIThreadNode {
+	nodes := map[uuid.UUID]*APIThreadNode{}
+	children := map[uuid.UUID][]uuid.UUID{}
+
+	for i := range apiChirps {
+		if unavailable[apiChirps[i].ID] {
+			nodes[apiChirps[i].ID] = &APIThreadNode{ID: apiChirps[i].ID, Unavailable: true}
+			continue
+		}
+
+		nodes[apiChirps[i].ID] = &APIThreadNode{
+			ID:    apiChirps[i].ID,
+			Chirp: &apiChirps[i],
+		}
+	}
+
+	if _, ok := nodes[rootID]; !ok {
+		nodes[rootID] = &APIThreadNode{ID: rootID, Deleted: true}
+	}
+
+	for _, apiChirp := range apiChirps {
+		if apiChirp.ID == rootID {
+			continue
+		}
+
+		parentID := rootID
+		if apiChirp.InReplyToID.Valid {
+			parentID = apiChirp.InReplyToID.UUID
+		}
+
+		if _, ok := nodes[parentID]; !ok {
+			nodes[parentID] = &APIThreadNode{ID: parentID, Deleted: true}
+			children[rootID] = append(children[rootID], parentID)
+		}
+
+		children[parentID] = append(children[parentID], apiChirp.ID)
+	}
+
+	var build func(id uuid.UUID) APIThreadNode
+	build = func(id uuid.UUID) APIThreadNode {
+		node := *nodes[id]
+		node.Replies = make([]APIThreadNode, len(children[id]))
+		for i, childID := range children[id] {
+			node.Replies[i] = build(childID)
+		}
+		return node
+	}
+
+	return build(rootID)
+}
+
+func NewAPIChirpHistory(dbChirp *database.Chirp, dbRevisions []database.ChirpRevision) APIChirpHistory {
+	revisions := make([]APIChirpRevision, 0, len(dbRevisions)+1)
+	for _, dbRevision := range dbRevisions {
+		replacedAt := dbRevision.ReplacedAt
+		revisions = append(revisions, APIChirpRevision{
+			Body:       dbRevision.Body,
+			AuthoredAt: dbRevision.AuthoredAt,
+			ReplacedAt: &replacedAt,
+		})
+	}
+
+	revisions = append(revisions, APIChirpRevision{
+		Body:       dbChirp.Body,
+		AuthoredAt: dbChirp.UpdatedAt,
+	})
+
+	return APIChirpHistory{
+		ChirpID:   dbChirp.ID,
+		Revisions: revisions,
+	}
+}
+
+func NewAPITrendingTag(row *database.GetTrendingTagsRow) APITrendingTag {
+	return APITrendingTag{
+		Tag:   row.Name,
+		Score: row.Score,
+		Uses:  row.Uses,
 	}
 }
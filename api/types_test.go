@@ -0,0 +1,97 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewAPIThread(t *testing.T) {
+	rootID := uuid.New()
+	deletedID := uuid.New()
+	replyID := uuid.New()
+	orphanID := uuid.New()
+
+	reply := func(id, parentID uuid.UUID) APIChirp {
+		return APIChirp{
+			ID:          id,
+			InReplyToID: uuid.NullUUID{UUID: parentID, Valid: true},
+			ThreadID:    rootID,
+		}
+	}
+
+	t.Run("Live root", func(t *testing.T) {
+		thread := NewAPIThread(rootID, []APIChirp{
+			{ID: rootID, ThreadID: rootID},
+			reply(replyID, rootID),
+			reply(orphanID, deletedID),
+		}, nil)
+
+		if thread.Deleted || thread.Chirp == nil {
+			t.Fatal("expected live root")
+		}
+		if len(thread.Replies) != 2 {
+			t.Fatalf("expected 2 replies under root, got %d", len(thread.Replies))
+		}
+		if thread.Replies[0].ID != replyID {
+			t.Errorf("expected first reply %v, got %v", replyID, thread.Replies[0].ID)
+		}
+
+		tombstone := thread.Replies[1]
+		if tombstone.ID != deletedID || !tombstone.Deleted || tombstone.Chirp != nil {
+			t.Fatalf("expected tombstone for %v, got %+v", deletedID, tombstone)
+		}
+		if len(tombstone.Replies) != 1 || tombstone.Replies[0].ID != orphanID {
+			t.Errorf("expected orphan under tombstone, got %+v", tombstone.Replies)
+		}
+	})
+
+	t.Run("Deleted root", func(t *testing.T) {
+		thread := NewAPIThread(rootID, []APIChirp{
+			reply(replyID, rootID),
+		}, nil)
+
+		if !thread.Deleted || thread.ID != rootID {
+			t.Fatalf("expected root tombstone, got %+v", thread)
+		}
+		if len(thread.Replies) != 1 || thread.Replies[0].ID != replyID {
+			t.Errorf("expected reply under root tombstone, got %+v", thread.Replies)
+		}
+	})
+
+	t.Run("Unavailable reply", func(t *testing.T) {
+		hiddenID := uuid.New()
+		thread := NewAPIThread(rootID, []APIChirp{
+			{ID: rootID, ThreadID: rootID},
+			reply(hiddenID, rootID),
+			reply(replyID, hiddenID),
+		}, map[uuid.UUID]bool{hiddenID: true})
+
+		if len(thread.Replies) != 1 {
+			t.Fatalf("expected 1 reply under root, got %d", len(thread.Replies))
+		}
+		tombstone := thread.Replies[0]
+		if tombstone.ID != hiddenID || !tombstone.Unavailable || tombstone.Deleted || tombstone.Chirp != nil {
+			t.Fatalf("expected unavailable tombstone for %v, got %+v", hiddenID, tombstone)
+		}
+		if len(tombstone.Replies) != 1 || tombstone.Replies[0].ID != replyID {
+			t.Errorf("expected reply under unavailable tombstone, got %+v", tombstone.Replies)
+		}
+	})
+}
+
+func TestNewAPIQuotedChirp(t *testing.T) {
+	visibleID, hiddenID, deletedID := uuid.New(), uuid.New(), uuid.New()
+	quoted := map[uuid.UUID]APIChirp{visibleID: {ID: visibleID}}
+	unavailable := map[uuid.UUID]bool{hiddenID: true}
+
+	if got := NewAPIQuotedChirp(visibleID, quoted, unavailable); got.Deleted || got.Unavailable || got.Chirp == nil {
+		t.Errorf("visible quote = %+v, want the chirp embedded", got)
+	}
+	if got := NewAPIQuotedChirp(hiddenID, quoted, unavailable); got.Deleted || !got.Unavailable || got.Chirp != nil {
+		t.Errorf("unavailable quote = %+v, want an unavailable tombstone", got)
+	}
+	if got := NewAPIQuotedChirp(deletedID, quoted, unavailable); !got.Deleted || got.Unavailable || got.Chirp != nil {
+		t.Errorf("deleted quote = %+v, want a deleted tombstone", got)
+	}
+}
@@ -1,15 +1,21 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
 
 	"github.com/mmycroft/boot-dev-chirpy/auth"
 
 	"github.com/mmycroft/boot-dev-chirpy/database"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // HandlerCreateUser POST /api/users
@@ -18,6 +24,10 @@ func (cfg *APIConfig) HandlerCreateUser(wr http.ResponseWriter, req *http.Reques
 		Email       string `json:"email"`
 		Password    string `json:"password"`
 		IsChirpyRed bool   `json:"is_chirpy_red"`
+		Handle      string `json:"handle"`
+		DisplayName string `json:"display_name"`
+		Bio         string `json:"bio"`
+		AvatarURL   string `json:"avatar_url"`
 	}{}
 
 	if err := json.NewDecoder(req.Body).Decode(&userData); err != nil {
@@ -33,20 +43,36 @@ func (cfg *APIConfig) HandlerCreateUser(wr http.ResponseWriter, req *http.Reques
 		return
 	}
 
+	if err := validateProfile(&userData.Handle, &userData.DisplayName, &userData.Bio, &userData.AvatarURL); err != nil {
+		log.Printf("error validating profile: %v\n", err)
+		respondWithError(wr, err, http.StatusBadRequest)
+		return
+	}
+
 	userParams := database.CreateUserParams{
 		Email:          userData.Email,
 		HashedPassword: hashedPassword,
 		IsChirpyRed:    userData.IsChirpyRed,
+		Handle:         userData.Handle,
+		DisplayName:    userData.DisplayName,
+		Bio:            userData.Bio,
+		AvatarUrl:      userData.AvatarURL,
 	}
 
 	dbUser, err := cfg.DBQueries.CreateUser(req.Context(), userParams)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == _UNIQUE_VIOLATION {
+			err = fmt.Errorf("email or handle is already taken")
+			log.Println(err)
+			respondWithError(wr, err, http.StatusConflict)
+			return
+		}
 		log.Printf("error creating database user: %v\n", err)
 		respondWithError(wr, err, http.StatusInternalServerError)
 		return
 	}
 
-	apiUser := NewAPIUser(&dbUser, "", "")
+	apiUser := NewAPIUser(&dbUser, cfg.Storage, "", "")
 
 	respondWithJSON(wr, apiUser, http.StatusCreated)
 }
@@ -59,12 +85,12 @@ func (cfg *APIConfig) HandlerGetUsers(wr http.ResponseWriter, req *http.Request)
 		respondWithError(wr, err, http.StatusNotFound)
 		return
 	}
-	apiUsers := make([]APIUser, len(dbUsers))
+	apiProfiles := make([]APIProfile, len(dbUsers))
 	for i, dbUser := range dbUsers {
-		apiUsers[i] = NewAPIUser(&dbUser, "", "")
+		apiProfiles[i] = NewAPIProfile(&dbUser, cfg.Storage)
 	}
 
-	respondWithJSON(wr, apiUsers, http.StatusOK)
+	respondWithJSON(wr, apiProfiles, http.StatusOK)
 }
 
 // HandlerGetUser GET /api/users/{userID}
@@ -83,31 +109,63 @@ func (cfg *APIConfig) HandlerGetUser(wr http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	apiUser := NewAPIUser(&dbUser, "", "")
+	apiProfile := NewAPIProfile(&dbUser, cfg.Storage)
 
-	respondWithJSON(wr, apiUser, http.StatusOK)
+	respondWithJSON(wr, apiProfile, http.StatusOK)
 }
 
-// HandlerUpdateUser PUT /api/users
-func (cfg *APIConfig) HandlerUpdateUser(wr http.ResponseWriter, req *http.Request) {
-	accessToken, err := auth.GetBearerToken(req.Header)
+// HandlerGetUserByHandle GET /api/users/by-handle/{handle}
+func (cfg *APIConfig) HandlerGetUserByHandle(wr http.ResponseWriter, req *http.Request) {
+	dbUser, err := cfg.DBQueries.GetUserByHandle(req.Context(), req.PathValue("handle"))
 	if err != nil {
-		log.Printf("error pulling access token from authorization header: %v\n", err)
-		respondWithError(wr, err, http.StatusUnauthorized)
+		log.Printf("error getting user from database: %v\n", err)
+		respondWithError(wr, err, http.StatusNotFound)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(accessToken, cfg.Secret)
+	apiProfile := NewAPIProfile(&dbUser, cfg.Storage)
+
+	respondWithJSON(wr, apiProfile, http.StatusOK)
+}
+
+// HandlerGetUserCollection GET /api/users/{userID}/{collection}
+//
+// ServeMux refuses to register GET /api/users/by-handle/{handle} next to
+// GET /api/users/{userID}/followers since a handle can be "followers", so
+// every GET of that shape comes through here and is dispatched by hand.
+func (cfg *APIConfig) HandlerGetUserCollection(wr http.ResponseWriter, req *http.Request) {
+	if req.PathValue("userID") == "by-handle" {
+		req.SetPathValue("handle", req.PathValue("collection"))
+		cfg.HandlerGetUserByHandle(wr, req)
+		return
+	}
+
+	switch collection := req.PathValue("collection"); collection {
+	case "followers":
+		cfg.HandlerGetFollowers(wr, req)
+	case "following":
+		cfg.HandlerGetFollowing(wr, req)
+	case "likes":
+		cfg.HandlerGetUserLikes(wr, req)
+	case "identicon":
+		cfg.HandlerGetIdenticon(wr, req)
+	default:
+		err := fmt.Errorf("unknown user collection %q", collection)
+		log.Println(err)
+		respondWithError(wr, err, http.StatusNotFound)
+	}
+}
+
+// HandlerUpdateUser PUT /api/users
+func (cfg *APIConfig) HandlerUpdateUser(wr http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
 	if err != nil {
-		log.Printf("error validating access token: %v\n", err)
+		log.Printf("error authenticating request: %v\n", err)
 		respondWithError(wr, err, http.StatusUnauthorized)
 		return
 	}
 
-	userData := struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}{}
+	userData := userUpdate{}
 
 	if err := json.NewDecoder(req.Body).Decode(&userData); err != nil {
 		log.Printf("error decoding request body: %v\n", err)
@@ -115,27 +173,27 @@ func (cfg *APIConfig) HandlerUpdateUser(wr http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(userData.Password)
+	userParams, err := newUpdateUserParams(userID, &userData)
 	if err != nil {
-		log.Printf("error hashing password: %v\n", err)
+		log.Printf("error validating user update: %v\n", err)
 		respondWithError(wr, err, http.StatusBadRequest)
 		return
 	}
 
-	userParams := database.UpdateUserParams{
-		ID:             userID,
-		Email:          userData.Email,
-		HashedPassword: hashedPassword,
-	}
-
 	dbUser, err := cfg.DBQueries.UpdateUser(req.Context(), userParams)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == _UNIQUE_VIOLATION {
+			err = fmt.Errorf("email or handle is already taken")
+			log.Println(err)
+			respondWithError(wr, err, http.StatusConflict)
+			return
+		}
 		log.Printf("error updating database user: %v\n", err)
 		respondWithError(wr, err, http.StatusInternalServerError)
 		return
 	}
 
-	apiUser := NewAPIUser(&dbUser, "", "")
+	apiUser := NewAPIUser(&dbUser, cfg.Storage, "", "")
 
 	respondWithJSON(wr, apiUser, http.StatusOK)
 }
@@ -174,8 +232,9 @@ func (cfg *APIConfig) HandlerUpgradeUser(wr http.ResponseWriter, req *http.Reque
 // HandlerLogin POST /api/login
 func (cfg *APIConfig) HandlerLogin(wr http.ResponseWriter, req *http.Request) {
 	userData := struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email       string `json:"email"`
+		Password    string `json:"password"`
+		DeviceLabel string `json:"device_label"`
 	}{}
 
 	if err := json.NewDecoder(req.Body).Decode(&userData); err != nil {
@@ -197,9 +256,26 @@ func (cfg *APIConfig) HandlerLogin(wr http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	accessToken, err := auth.MakeJWT(dbUser.ID, cfg.Secret)
+	if dbUser.SuspendedAt.Valid {
+		err := fmt.Errorf("account has been suspended")
+		log.Println(err)
+		respondWithError(wr, err, http.StatusForbidden)
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(req.Context(), nil)
 	if err != nil {
-		log.Printf("error making JWT token: %v\n", err)
+		log.Printf("error starting transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.DBQueries.WithTx(tx)
+
+	dbSession, err := qtx.CreateSession(req.Context(), newSessionParams(req, dbUser.ID, userData.DeviceLabel))
+	if err != nil {
+		log.Printf("error creating session: %v\n", err)
 		respondWithError(wr, err, http.StatusInternalServerError)
 		return
 	}
@@ -211,19 +287,126 @@ func (cfg *APIConfig) HandlerLogin(wr http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	lookupPrefix, tokenHash := auth.HashRefreshToken(refreshTokenString)
+
+	// a login starts a new family of refresh tokens, named by its session
 	refreshTokenParams := database.CreateRefreshTokenParams{
-		Token:  refreshTokenString,
-		UserID: dbUser.ID,
+		LookupPrefix: lookupPrefix,
+		TokenHash:    tokenHash,
+		UserID:       dbUser.ID,
+		FamilyID:     dbSession.ID,
+		ExpiresAt:    time.Now().Add(cfg.RefreshTokenLifetime),
 	}
 
-	refreshToken, err := cfg.DBQueries.CreateRefreshToken(req.Context(), refreshTokenParams)
-	if err != nil {
+	if _, err := qtx.CreateRefreshToken(req.Context(), refreshTokenParams); err != nil {
 		log.Printf("error creating refresh token: %v\n", err)
 		respondWithError(wr, err, http.StatusInternalServerError)
 		return
 	}
 
-	apiUser := NewAPIUser(&dbUser, accessToken, refreshToken.Token)
+	accessToken, err := cfg.makeAccessToken(req.Context(), qtx, &dbUser, dbSession.ID)
+	if err != nil {
+		log.Printf("error making JWT token: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("error committing transaction: %v\n", err)
+		respondWithError(wr, err, http.StatusInternalServerError)
+		return
+	}
+
+	apiUser := NewAPIUser(&dbUser, cfg.Storage, accessToken, refreshTokenString)
 
 	respondWithJSON(wr, apiUser, http.StatusOK)
 }
+
+const (
+	_MAX_DISPLAY_NAME_LENGTH = 50
+	_MAX_BIO_LENGTH          = 160
+	_MAX_AVATAR_URL_LENGTH   = 2048
+)
+
+// userUpdate is the body of PUT /api/users. Every field is optional, nil
+// fields are left as they are.
+type userUpdate struct {
+	Email       *string `json:"email"`
+	Password    *string `json:"password"`
+	Handle      *string `json:"handle"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	AvatarURL   *string `json:"avatar_url"`
+}
+
+// newUpdateUserParams validates update and turns it into the query
+// parameters for userID, hashing the password only when one was sent
+func newUpdateUserParams(userID uuid.UUID, update *userUpdate) (database.UpdateUserParams, error) {
+	if update.Email != nil && *update.Email == "" {
+		return database.UpdateUserParams{}, fmt.Errorf("email cannot be empty")
+	}
+
+	hashedPassword := sql.NullString{}
+	if update.Password != nil {
+		if *update.Password == "" {
+			return database.UpdateUserParams{}, fmt.Errorf("password cannot be empty")
+		}
+
+		hashed, err := auth.HashPassword(*update.Password)
+		if err != nil {
+			return database.UpdateUserParams{}, fmt.Errorf("error hashing password: %w", err)
+		}
+		hashedPassword = sql.NullString{String: hashed, Valid: true}
+	}
+
+	if err := validateProfile(update.Handle, update.DisplayName, update.Bio, update.AvatarURL); err != nil {
+		return database.UpdateUserParams{}, err
+	}
+
+	return database.UpdateUserParams{
+		ID:             userID,
+		Email:          nullString(update.Email),
+		HashedPassword: hashedPassword,
+		Handle:         nullString(update.Handle),
+		DisplayName:    nullString(update.DisplayName),
+		Bio:            nullString(update.Bio),
+		AvatarUrl:      nullString(update.AvatarURL),
+	}, nil
+}
+
+// validateProfile checks the public profile fields of a user. Nil fields are
+// not being set and are skipped, but a handle that is set cannot be empty.
+func validateProfile(handle, displayName, bio, avatarURL *string) error {
+	if handle != nil && !handleRegexp.MatchString(*handle) {
+		return fmt.Errorf("handle must be 1-30 letters, digits or underscores")
+	}
+
+	if displayName != nil && utf8.RuneCountInString(*displayName) > _MAX_DISPLAY_NAME_LENGTH {
+		return fmt.Errorf("display name must be %d characters or less", _MAX_DISPLAY_NAME_LENGTH)
+	}
+
+	if bio != nil && utf8.RuneCountInString(*bio) > _MAX_BIO_LENGTH {
+		return fmt.Errorf("bio must be %d characters or less", _MAX_BIO_LENGTH)
+	}
+
+	if avatarURL != nil && *avatarURL != "" {
+		if len(*avatarURL) > _MAX_AVATAR_URL_LENGTH {
+			return fmt.Errorf("avatar url must be %d characters or less", _MAX_AVATAR_URL_LENGTH)
+		}
+
+		u, err := url.Parse(*avatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("avatar url must be an http or https url")
+		}
+	}
+
+	return nil
+}
+
+// nullString turns an optional request field into a nullable query parameter
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
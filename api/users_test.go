@@ -0,0 +1,57 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/mmycroft/boot-dev-chirpy/auth"
+)
+
+func TestNewUpdateUserParams(t *testing.T) {
+	userID := uuid.New()
+	bio := "x"
+	empty := ""
+	email := "new@example.com"
+	password := "hunter2"
+
+	t.Run("Profile only", func(t *testing.T) {
+		params, err := newUpdateUserParams(userID, &userUpdate{Bio: &bio})
+		if err != nil {
+			t.Fatalf("newUpdateUserParams() error = %v", err)
+		}
+		if params.Email.Valid || params.HashedPassword.Valid {
+			t.Errorf("expected email and password to be left unchanged, got %+v", params)
+		}
+		if !params.Bio.Valid || params.Bio.String != bio {
+			t.Errorf("expected bio %q, got %+v", bio, params.Bio)
+		}
+	})
+
+	t.Run("Email and password", func(t *testing.T) {
+		params, err := newUpdateUserParams(userID, &userUpdate{Email: &email, Password: &password})
+		if err != nil {
+			t.Fatalf("newUpdateUserParams() error = %v", err)
+		}
+		if !params.Email.Valid || params.Email.String != email {
+			t.Errorf("expected email %q, got %+v", email, params.Email)
+		}
+		if !params.HashedPassword.Valid || auth.CheckPasswordHash(password, params.HashedPassword.String) != nil {
+			t.Errorf("expected a hash of the new password, got %+v", params.HashedPassword)
+		}
+		if params.Bio.Valid {
+			t.Errorf("expected bio to be left unchanged, got %+v", params.Bio)
+		}
+	})
+
+	for name, update := range map[string]userUpdate{
+		"Empty email":    {Email: &empty},
+		"Empty password": {Password: &empty},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := newUpdateUserParams(userID, &update); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
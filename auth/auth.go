@@ -3,6 +3,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"log"
@@ -31,21 +32,55 @@ func CheckPasswordHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
+// Issuer is the iss of every access token
+const Issuer = "chirpy"
+
+// CustomClaims are what an access token says about its user beyond who they
+// are. They are a snapshot from when the token was issued.
+type CustomClaims struct {
+	// SessionID is the session the token was issued for, so revoking the
+	// session can stop the token working before it expires
+	SessionID   string   `json:"sid,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Scopes      []string `json:"scopes,omitempty"`
+	IsChirpyRed bool     `json:"is_chirpy_red,omitempty"`
+}
+
+// Claims are the claims of an access token
+type Claims struct {
+	jwt.RegisteredClaims
+	CustomClaims
+}
+
 // MakeJWT creates and returns JWT
-func MakeJWT(userID uuid.UUID, tokenSecret string) (string, error) {
+func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return NewHMACKeyring(tokenSecret).MakeJWT(userID, expiresIn, CustomClaims{})
+}
+
+// ValidateJWT validates a token string agains the secret
+func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	return NewHMACKeyring(tokenSecret).ValidateJWT(tokenString)
+}
+
+// MakeJWT creates and returns a JWT for userID signed with the signing key,
+// expiring after expiresIn and carrying custom
+func (k *Keyring) MakeJWT(userID uuid.UUID, expiresIn time.Duration, custom CustomClaims) (string, error) {
 	now := time.Now()
 
-	registeredClaims := jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(now),
-		ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(1) * time.Hour)),
-		Subject:   userID.String(),
-		ID:        userID.String(),
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    Issuer,
+			Audience:  jwt.ClaimStrings{k.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			Subject:   userID.String(),
+			ID:        uuid.NewString(),
+		},
+		CustomClaims: custom,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, registeredClaims)
-
-	signedString, err := token.SignedString([]byte(tokenSecret))
+	signedString, err := k.sign(claims)
 	if err != nil {
 		log.Printf("error signing jwt token: %v\n", err)
 		return "", fmt.Errorf("error signing jwt token: %w", err)
@@ -54,22 +89,31 @@ func MakeJWT(userID uuid.UUID, tokenSecret string) (string, error) {
 	return signedString, nil
 }
 
-// ValidateJWT validates a token string agains the secret
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	keyFunc := func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			log.Printf("the token does not have signing method HMAC: %v\n", t.Header["alg"])
-			return nil, fmt.Errorf("incorrect signing method: %v", t.Header["alg"])
-		}
-		return []byte(tokenSecret), nil
-	}
-	fmt.Printf("\nbefore parse with claims \n\ntokenString: %s\n\n", tokenString)
-	claims := &jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
+// ParseJWT validates a token string against the key named by its kid and
+// returns its claims. The token must be from Issuer for the keyring's
+// Audience, and its times may be off by up to Leeway.
+func (k *Keyring) ParseJWT(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, k.keyFunc,
+		jwt.WithIssuer(Issuer),
+		jwt.WithAudience(k.Audience),
+		jwt.WithLeeway(k.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
 	if err != nil || !token.Valid {
-		return uuid.Nil, fmt.Errorf("invalid token: %w", err)
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	return claims, nil
+}
+
+// ValidateJWT validates a token string and returns its user id
+func (k *Keyring) ValidateJWT(tokenString string) (uuid.UUID, error) {
+	claims, err := k.ParseJWT(tokenString)
+	if err != nil {
+		return uuid.Nil, err
 	}
-	fmt.Println("after parse with claims")
 
 	id, err := uuid.Parse(claims.Subject)
 	if err != nil {
@@ -110,3 +154,20 @@ func MakeRefreshToken() (string, error) {
 
 	return hex.EncodeToString(b), nil
 }
+
+// RefreshTokenPrefixLength is how much of a refresh token is kept in the
+// clear to find it by
+const RefreshTokenPrefixLength = 16
+
+// HashRefreshToken returns the lookup prefix and hex SHA-256 digest a
+// refresh token is stored under. The prefix only finds the row, it is the
+// digest that proves the token.
+func HashRefreshToken(token string) (prefix, digest string) {
+	prefix = token
+	if len(prefix) > RefreshTokenPrefixLength {
+		prefix = prefix[:RefreshTokenPrefixLength]
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	return prefix, hex.EncodeToString(sum[:])
+}
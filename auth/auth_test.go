@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -120,8 +121,21 @@ func TestMakeJWT(t *testing.T) {
 		t.Errorf("expected sub claim %q, got %q", userID.String(), claims["sub"])
 	}
 
-	if claims["jti"] != userID.String() {
-		t.Errorf("expected jti claim %q, got %q", userID.String(), claims["jti"])
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" || jti == userID.String() {
+		t.Errorf("expected a jti claim of its own, got %q", claims["jti"])
+	}
+
+	otherTokenStr, err := MakeJWT(userID, secret, expiresIn)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	otherToken, _, err := jwt.NewParser().ParseUnverified(otherTokenStr, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if otherToken.Claims.(jwt.MapClaims)["jti"] == jti {
+		t.Errorf("expected two tokens to have different jti claims, both got %q", jti)
 	}
 
 	exp, ok := claims["exp"].(float64) // exp is usually float64 (unix timestamp)
@@ -180,3 +194,78 @@ func TestValidateJWT(t *testing.T) {
 		})
 	}
 }
+
+func TestKeyringClaims(t *testing.T) {
+	userID := uuid.New()
+	keys := NewHMACKeyring("secret")
+
+	custom := CustomClaims{
+		SessionID:   uuid.NewString(),
+		Roles:       []string{"moderator"},
+		Scopes:      []string{"chirps:write"},
+		IsChirpyRed: true,
+	}
+	token, err := keys.MakeJWT(userID, time.Hour, custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := keys.ParseJWT(token)
+	if err != nil {
+		t.Fatalf("ParseJWT() error = %v", err)
+	}
+	if claims.Subject != userID.String() || claims.SessionID != custom.SessionID || !claims.IsChirpyRed ||
+		strings.Join(claims.Roles, ",") != "moderator" || strings.Join(claims.Scopes, ",") != "chirps:write" {
+		t.Errorf("ParseJWT() claims = %+v, want subject %v and %+v", claims, userID, custom)
+	}
+
+	other := NewHMACKeyring("secret")
+	other.Audience = "someone-else"
+	if _, err := other.ParseJWT(token); err == nil {
+		t.Error("ParseJWT() for another audience expected error, got nil")
+	}
+
+	// expired a little less than the leeway ago
+	skewed, err := keys.MakeJWT(userID, -keys.Leeway/2, CustomClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.ValidateJWT(skewed); err != nil {
+		t.Errorf("ValidateJWT() within leeway error = %v", err)
+	}
+
+	expired, err := keys.MakeJWT(userID, -2*keys.Leeway, CustomClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.ValidateJWT(expired); err == nil {
+		t.Error("ValidateJWT() past leeway expected error, got nil")
+	}
+}
+
+func TestHashRefreshToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	prefix, digest := HashRefreshToken(token)
+	if prefix != token[:RefreshTokenPrefixLength] {
+		t.Errorf("HashRefreshToken() prefix = %q, want the first %d characters of the token", prefix, RefreshTokenPrefixLength)
+	}
+	if len(digest) != 64 || strings.Contains(digest, token) {
+		t.Errorf("HashRefreshToken() digest = %q, want a 64 character hex digest", digest)
+	}
+
+	if _, again := HashRefreshToken(token); again != digest {
+		t.Error("HashRefreshToken() is not deterministic")
+	}
+
+	other, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, otherDigest := HashRefreshToken(other); otherDigest == digest {
+		t.Error("HashRefreshToken() gave two tokens the same digest")
+	}
+}
@@ -0,0 +1,331 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultKeyID is the kid of the key made from the SECRET env var. Tokens
+// from before kid headers were added have none and are checked with it.
+const DefaultKeyID = "default"
+
+const (
+	// DefaultAudience is the aud of access tokens for the chirpy api
+	DefaultAudience = "chirpy-api"
+	// DefaultLeeway is how far apart the clocks of the services issuing and
+	// checking a token may be
+	DefaultLeeway = 30 * time.Second
+
+	// _MIN_RSA_BITS is the smallest RSA key accepted for RS256
+	_MIN_RSA_BITS = 2048
+)
+
+// Key is one key of a Keyring, known to tokens by its kid
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// private signs tokens and public checks them, both are the secret for
+	// HMAC keys
+	private any
+	public  any
+}
+
+// Keyring holds every key access tokens can be signed with. One of them
+// signs new tokens and all of them are trusted, so a key can be rotated by
+// adding the new one, signing with it and dropping the old one once its
+// tokens have expired. A Keyring is set up before serving and must not be
+// changed after.
+type Keyring struct {
+	// Audience is the aud of new tokens and the one parsed tokens must have
+	Audience string
+	// Leeway is how far a token's times may be off from the local clock
+	Leeway time.Duration
+
+	keys    map[string]*Key
+	ids     []string
+	signing *Key
+}
+
+// NewKeyring returns an empty Keyring for DefaultAudience
+func NewKeyring() *Keyring {
+	return &Keyring{
+		Audience: DefaultAudience,
+		Leeway:   DefaultLeeway,
+		keys:     map[string]*Key{},
+	}
+}
+
+// NewHMACKeyring returns a Keyring holding secret as its only, HS256, key
+func NewHMACKeyring(secret string) *Keyring {
+	k := NewKeyring()
+	// the only way to fail is a duplicate kid, which cannot happen here
+	_ = k.AddHMAC(DefaultKeyID, []byte(secret))
+	return k
+}
+
+func (k *Keyring) add(key *Key) error {
+	if key.ID == "" {
+		return fmt.Errorf("key id is empty")
+	}
+	if _, ok := k.keys[key.ID]; ok {
+		return fmt.Errorf("key %q is already in the keyring", key.ID)
+	}
+
+	k.keys[key.ID] = key
+	k.ids = append(k.ids, key.ID)
+
+	// the first key signs until told otherwise
+	if k.signing == nil {
+		k.signing = key
+	}
+
+	return nil
+}
+
+// AddHMAC adds an HS256 key
+func (k *Keyring) AddHMAC(kid string, secret []byte) error {
+	return k.add(&Key{
+		ID:      kid,
+		Method:  jwt.SigningMethodHS256,
+		private: secret,
+		public:  secret,
+	})
+}
+
+// AddSigner adds an asymmetric key, using EdDSA for Ed25519 keys, RS256 for
+// RSA keys and ES256 for ECDSA keys on P-256
+func (k *Keyring) AddSigner(kid string, signer crypto.Signer) error {
+	key := &Key{
+		ID:      kid,
+		private: signer,
+		public:  signer.Public(),
+	}
+
+	switch signer := signer.(type) {
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+	case *rsa.PrivateKey:
+		if signer.N.BitLen() < _MIN_RSA_BITS {
+			return fmt.Errorf("key %q: RSA keys must be at least %d bits", kid, _MIN_RSA_BITS)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		if signer.Curve != elliptic.P256() {
+			return fmt.Errorf("key %q: ECDSA keys must be on P-256", kid)
+		}
+		key.Method = jwt.SigningMethodES256
+	default:
+		return fmt.Errorf("key %q: unsupported key type %T", kid, signer)
+	}
+
+	return k.add(key)
+}
+
+// SetSigningKey picks the key new tokens are signed with
+func (k *Keyring) SetSigningKey(kid string) error {
+	key, ok := k.keys[kid]
+	if !ok {
+		return fmt.Errorf("key %q is not in the keyring", kid)
+	}
+	k.signing = key
+	return nil
+}
+
+// SigningKeyID is the kid of the key new tokens are signed with
+func (k *Keyring) SigningKeyID() string {
+	if k.signing == nil {
+		return ""
+	}
+	return k.signing.ID
+}
+
+// LoadDir adds every key in dir, named by its file name: <kid>.pem files
+// hold a PEM encoded private key and <kid>.key files an HMAC secret. Other
+// files are skipped.
+func (k *Keyring) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading key directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if ext != ".pem" && ext != ".key" {
+			continue
+		}
+		kid := strings.TrimSuffix(name, ext)
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("error reading key %q: %w", kid, err)
+		}
+
+		if ext == ".key" {
+			secret := []byte(strings.TrimSpace(string(data)))
+			if len(secret) == 0 {
+				return fmt.Errorf("key %q is empty", kid)
+			}
+			if err := k.AddHMAC(kid, secret); err != nil {
+				return err
+			}
+			continue
+		}
+
+		signer, err := ParsePrivateKeyPEM(data)
+		if err != nil {
+			return fmt.Errorf("error parsing key %q: %w", kid, err)
+		}
+		if err := k.AddSigner(kid, signer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ParsePrivateKeyPEM parses a PKCS #8, PKCS #1 RSA or SEC 1 EC private key
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+
+	return signer, nil
+}
+
+// sign signs claims with the signing key, naming it in the kid header
+func (k *Keyring) sign(claims jwt.Claims) (string, error) {
+	if k.signing == nil {
+		return "", fmt.Errorf("keyring has no signing key")
+	}
+
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+
+	return token.SignedString(k.signing.private)
+}
+
+// keyFunc finds the key a token was signed with by its kid header. The
+// token's alg has to be the key's own, so a public key can never be used
+// as an HMAC secret.
+func (k *Keyring) keyFunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		kid = DefaultKeyID
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("incorrect signing method: %v", t.Header["alg"])
+	}
+
+	return key.public, nil
+}
+
+// JWK is a public key in the JSON Web Key format of RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public halves of the asymmetric keys, sorted by kid, for
+// other services to check tokens with. HMAC keys are secret and left out.
+func (k *Keyring) JWKS() JWKS {
+	ids := append([]string{}, k.ids...)
+	sort.Strings(ids)
+
+	set := JWKS{Keys: []JWK{}}
+	for _, kid := range ids {
+		key := k.keys[kid]
+		jwk := JWK{
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: key.Method.Alg(),
+		}
+
+		switch public := key.public.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			ecdhKey, err := public.ECDH()
+			if err != nil {
+				continue
+			}
+			// an uncompressed point is 0x04 followed by x and y
+			point := ecdhKey.Bytes()[1:]
+			jwk.KeyType = "EC"
+			jwk.Curve = "P-256"
+			jwk.X = base64.RawURLEncoding.EncodeToString(point[:len(point)/2])
+			jwk.Y = base64.RawURLEncoding.EncodeToString(point[len(point)/2:])
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
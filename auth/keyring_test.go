@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func testSigners(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]crypto.Signer{"EdDSA": edKey, "RS256": rsaKey, "ES256": ecKey}
+}
+
+func TestKeyringAlgorithms(t *testing.T) {
+	userID := uuid.New()
+
+	for alg, signer := range testSigners(t) {
+		t.Run(alg, func(t *testing.T) {
+			keys := NewKeyring()
+			if err := keys.AddSigner("k1", signer); err != nil {
+				t.Fatalf("AddSigner() error = %v", err)
+			}
+
+			token, err := keys.MakeJWT(userID, time.Hour, CustomClaims{})
+			if err != nil {
+				t.Fatalf("MakeJWT() error = %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Method.Alg() != alg || parsed.Header["kid"] != "k1" {
+				t.Errorf("token header = %v, want alg %s and kid k1", parsed.Header, alg)
+			}
+
+			gotUserID, err := keys.ValidateJWT(token)
+			if err != nil || gotUserID != userID {
+				t.Errorf("ValidateJWT() = %v, %v, want %v", gotUserID, err, userID)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	userID := uuid.New()
+	signers := testSigners(t)
+
+	keys := NewHMACKeyring("secret")
+	oldToken, err := keys.MakeJWT(userID, time.Hour, CustomClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyClaims := jwt.RegisteredClaims{
+		Issuer:    Issuer,
+		Audience:  jwt.ClaimStrings{DefaultAudience},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+	}
+	legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, legacyClaims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := keys.AddSigner("next", signers["ES256"]); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.SetSigningKey("next"); err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := keys.MakeJWT(userID, time.Hour, CustomClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"old key": oldToken, "no kid": legacyToken, "new key": newToken} {
+		if _, err := keys.ValidateJWT(token); err != nil {
+			t.Errorf("ValidateJWT() with %s error = %v", name, err)
+		}
+	}
+
+	// a keyring that no longer has the old key
+	retired := NewKeyring()
+	if err := retired.AddSigner("next", signers["ES256"]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := retired.ValidateJWT(oldToken); err == nil {
+		t.Error("ValidateJWT() accepted a token signed with a retired key")
+	}
+	if _, err := retired.ValidateJWT(newToken); err != nil {
+		t.Errorf("ValidateJWT() error = %v", err)
+	}
+}
+
+func TestKeyringRejectsAlgorithmSwap(t *testing.T) {
+	signer := testSigners(t)["RS256"]
+	keys := NewKeyring()
+	if err := keys.AddSigner("rsa", signer); err != nil {
+		t.Fatal(err)
+	}
+
+	// an HMAC token keyed with the public key, which anyone can fetch
+	publicDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: uuid.New().String()})
+	token.Header["kid"] = "rsa"
+	forged, err := token.SignedString(publicDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keys.ValidateJWT(forged); err == nil {
+		t.Error("ValidateJWT() accepted an HS256 token for an RSA key")
+	}
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	keys := NewHMACKeyring("secret")
+	for alg, signer := range testSigners(t) {
+		if err := keys.AddSigner(alg, signer); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set := keys.JWKS()
+	if len(set.Keys) != 3 {
+		t.Fatalf("JWKS() has %d keys, want the 3 asymmetric ones", len(set.Keys))
+	}
+
+	want := map[string]string{"ES256": "EC", "EdDSA": "OKP", "RS256": "RSA"}
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != want[jwk.KeyID] || jwk.Algorithm != jwk.KeyID || jwk.Use != "sig" {
+			t.Errorf("JWKS() key %q = %+v", jwk.KeyID, jwk)
+		}
+	}
+}
+
+func TestKeyringLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	der, err := x509.MarshalPKCS8PrivateKey(testSigners(t)["EdDSA"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"2025-01.pem": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+		"legacy.key":  []byte("a secret\n"),
+		"README":      []byte("not a key"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := NewKeyring()
+	if err := keys.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir() error = %v", err)
+	}
+
+	if err := keys.SetSigningKey("legacy"); err != nil {
+		t.Errorf("SetSigningKey(legacy) error = %v", err)
+	}
+	if set := keys.JWKS(); len(set.Keys) != 1 || set.Keys[0].KeyID != "2025-01" {
+		t.Errorf("JWKS() = %+v, want only key 2025-01", set)
+	}
+}
@@ -7,23 +7,36 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2)
-RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to_id, thread_id, quoted_chirp_id, is_rechirp)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to_id, thread_id, quoted_chirp_id, is_rechirp, search_vector, hidden_at
 `
 
 type CreateChirpParams struct {
-	Body   string    `json:"body"`
-	UserID uuid.UUID `json:"user_id"`
+	Body          string        `json:"body"`
+	UserID        uuid.UUID     `json:"user_id"`
+	InReplyToID   uuid.NullUUID `json:"in_reply_to_id"`
+	ThreadID      uuid.NullUUID `json:"thread_id"`
+	QuotedChirpID uuid.NullUUID `json:"quoted_chirp_id"`
+	IsRechirp     bool          `json:"is_rechirp"`
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyToID,
+		arg.ThreadID,
+		arg.QuotedChirpID,
+		arg.IsRechirp,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +44,12 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyToID,
+		&i.ThreadID,
+		&i.QuotedChirpID,
+		&i.IsRechirp,
+		&i.SearchVector,
+		&i.HiddenAt,
 	)
 	return i, err
 }
@@ -55,7 +74,7 @@ func (q *Queries) DeleteChirps(ctx context.Context) error {
 }
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, in_reply_to_id, thread_id, quoted_chirp_id, is_rechirp, search_vector, hidden_at
 FROM chirps
 WHERE chirps.id = $1
 `
@@ -69,12 +88,44 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyToID,
+		&i.ThreadID,
+		&i.QuotedChirpID,
+		&i.IsRechirp,
+		&i.SearchVector,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to_id, thread_id, quoted_chirp_id, is_rechirp, search_vector, hidden_at
+FROM chirps
+WHERE chirps.id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyToID,
+		&i.ThreadID,
+		&i.QuotedChirpID,
+		&i.IsRechirp,
+		&i.SearchVector,
+		&i.HiddenAt,
 	)
 	return i, err
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, in_reply_to_id, thread_id, quoted_chirp_id, is_rechirp, search_vector, hidden_at
 FROM chirps
 ORDER BY created_at ASC
 `
@@ -94,6 +145,318 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyToID,
+			&i.ThreadID,
+			&i.QuotedChirpID,
+			&i.IsRechirp,
+			&i.SearchVector,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT
+  chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to_id, chirps.thread_id, chirps.quoted_chirp_id, chirps.is_rechirp, chirps.search_vector, chirps.hidden_at,
+  chirps.user_id IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = $1::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = $1::uuid
+  ) AS muted_author
+FROM chirps
+WHERE chirps.id = ANY($2::uuid[])
+`
+
+type GetChirpsByIDsParams struct {
+	ViewerID uuid.UUID   `json:"viewer_id"`
+	ChirpIds []uuid.UUID `json:"chirp_ids"`
+}
+
+type GetChirpsByIDsRow struct {
+	Chirp       Chirp `json:"chirp"`
+	MutedAuthor bool  `json:"muted_author"`
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]GetChirpsByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, arg.ViewerID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpsByIDsRow
+	for rows.Next() {
+		var i GetChirpsByIDsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyToID,
+			&i.Chirp.ThreadID,
+			&i.Chirp.QuotedChirpID,
+			&i.Chirp.IsRechirp,
+			&i.Chirp.SearchVector,
+			&i.Chirp.HiddenAt,
+			&i.MutedAuthor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsPageAsc = `-- name: GetChirpsPageAsc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to_id, thread_id, quoted_chirp_id, is_rechirp, search_vector, hidden_at
+FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  AND ($2::timestamp IS NULL OR created_at >= $2)
+  AND ($3::timestamp IS NULL OR created_at < $3)
+  AND ($4::timestamp IS NULL OR (created_at, id) > ($4, $5::uuid))
+  AND ($6::uuid IS NULL OR id <> $6)
+  AND ($7::boolean OR hidden_at IS NULL)
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = $8::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = $8::uuid
+  )
+ORDER BY created_at ASC, id ASC
+LIMIT $9
+`
+
+type GetChirpsPageAscParams struct {
+	AuthorID        uuid.NullUUID `json:"author_id"`
+	Since           sql.NullTime  `json:"since"`
+	Until           sql.NullTime  `json:"until"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	ExcludeID       uuid.NullUUID `json:"exclude_id"`
+	IncludeHidden   bool          `json:"include_hidden"`
+	ViewerID        uuid.UUID     `json:"viewer_id"`
+	Limit           int32         `json:"limit"`
+}
+
+func (q *Queries) GetChirpsPageAsc(ctx context.Context, arg GetChirpsPageAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPageAsc,
+		arg.AuthorID,
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ExcludeID,
+		arg.IncludeHidden,
+		arg.ViewerID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyToID,
+			&i.ThreadID,
+			&i.QuotedChirpID,
+			&i.IsRechirp,
+			&i.SearchVector,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsPageDesc = `-- name: GetChirpsPageDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to_id, thread_id, quoted_chirp_id, is_rechirp, search_vector, hidden_at
+FROM chirps
+WHERE ($1::uuid IS NULL OR user_id = $1)
+  AND ($2::timestamp IS NULL OR created_at >= $2)
+  AND ($3::timestamp IS NULL OR created_at < $3)
+  AND ($4::timestamp IS NULL OR (created_at, id) < ($4, $5::uuid))
+  AND ($6::uuid IS NULL OR id <> $6)
+  AND ($7::boolean OR hidden_at IS NULL)
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = $8::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = $8::uuid
+  )
+ORDER BY created_at DESC, id DESC
+LIMIT $9
+`
+
+type GetChirpsPageDescParams struct {
+	AuthorID        uuid.NullUUID `json:"author_id"`
+	Since           sql.NullTime  `json:"since"`
+	Until           sql.NullTime  `json:"until"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	ExcludeID       uuid.NullUUID `json:"exclude_id"`
+	IncludeHidden   bool          `json:"include_hidden"`
+	ViewerID        uuid.UUID     `json:"viewer_id"`
+	Limit           int32         `json:"limit"`
+}
+
+func (q *Queries) GetChirpsPageDesc(ctx context.Context, arg GetChirpsPageDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPageDesc,
+		arg.AuthorID,
+		arg.Since,
+		arg.Until,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ExcludeID,
+		arg.IncludeHidden,
+		arg.ViewerID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyToID,
+			&i.ThreadID,
+			&i.QuotedChirpID,
+			&i.IsRechirp,
+			&i.SearchVector,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReplyCounts = `-- name: GetReplyCounts :many
+SELECT in_reply_to_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE in_reply_to_id = ANY($1::uuid[])
+  AND hidden_at IS NULL
+GROUP BY in_reply_to_id
+`
+
+type GetReplyCountsRow struct {
+	InReplyToID uuid.NullUUID `json:"in_reply_to_id"`
+	ReplyCount  int64         `json:"reply_count"`
+}
+
+func (q *Queries) GetReplyCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetReplyCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReplyCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReplyCountsRow
+	for rows.Next() {
+		var i GetReplyCountsRow
+		if err := rows.Scan(
+			&i.InReplyToID,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThread = `-- name: GetThread :many
+SELECT
+  chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to_id, chirps.thread_id, chirps.quoted_chirp_id, chirps.is_rechirp, chirps.search_vector, chirps.hidden_at,
+  chirps.user_id IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = $1::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = $1::uuid
+  ) AS muted_author
+FROM chirps
+WHERE (chirps.id = $2 OR chirps.thread_id = $2)
+ORDER BY chirps.created_at ASC, chirps.id ASC
+`
+
+type GetThreadParams struct {
+	ViewerID uuid.UUID `json:"viewer_id"`
+	ThreadID uuid.UUID `json:"thread_id"`
+}
+
+type GetThreadRow struct {
+	Chirp       Chirp `json:"chirp"`
+	MutedAuthor bool  `json:"muted_author"`
+}
+
+func (q *Queries) GetThread(ctx context.Context, arg GetThreadParams) ([]GetThreadRow, error) {
+	rows, err := q.db.QueryContext(ctx, getThread, arg.ViewerID, arg.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetThreadRow
+	for rows.Next() {
+		var i GetThreadRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.InReplyToID,
+			&i.Chirp.ThreadID,
+			&i.Chirp.QuotedChirpID,
+			&i.Chirp.IsRechirp,
+			&i.Chirp.SearchVector,
+			&i.Chirp.HiddenAt,
+			&i.MutedAuthor,
 		); err != nil {
 			return nil, err
 		}
@@ -107,3 +470,114 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	}
 	return items, nil
 }
+
+const getTimeline = `-- name: GetTimeline :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to_id, chirps.thread_id, chirps.quoted_chirp_id, chirps.is_rechirp, chirps.search_vector, chirps.hidden_at
+FROM chirps
+WHERE (chirps.user_id = $1
+    OR chirps.user_id IN (
+      SELECT follows.followee_id
+      FROM follows
+      WHERE follows.follower_id = $1
+    ))
+  AND chirps.hidden_at IS NULL
+  AND ($2::timestamp IS NULL OR (chirps.created_at, chirps.id) < ($2, $3::uuid))
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = $1
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = $1
+  )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $4
+`
+
+type GetTimelineParams struct {
+	UserID          uuid.UUID     `json:"user_id"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	Limit           int32         `json:"limit"`
+}
+
+func (q *Queries) GetTimeline(ctx context.Context, arg GetTimelineParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTimeline,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyToID,
+			&i.ThreadID,
+			&i.QuotedChirpID,
+			&i.IsRechirp,
+			&i.SearchVector,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hideChirp = `-- name: HideChirp :exec
+UPDATE chirps
+SET hidden_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+func (q *Queries) HideChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, hideChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  body = $2
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to_id, thread_id, quoted_chirp_id, is_rechirp, search_vector, hidden_at
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID `json:"id"`
+	Body string    `json:"body"`
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyToID,
+		&i.ThreadID,
+		&i.QuotedChirpID,
+		&i.IsRechirp,
+		&i.SearchVector,
+		&i.HiddenAt,
+	)
+	return i, err
+}
@@ -11,28 +11,208 @@ import (
 	"github.com/google/uuid"
 )
 
-type Chirp struct {
+type Block struct {
+	BlockerID uuid.UUID `json:"blocker_id"`
+	BlockedID uuid.UUID `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BookmarkCollection struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
 	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
 }
 
-type RefreshToken struct {
-	Token     string       `json:"token"`
+type Bookmark struct {
+	UserID       uuid.UUID     `json:"user_id"`
+	ChirpID      uuid.UUID     `json:"chirp_id"`
+	CollectionID uuid.NullUUID `json:"collection_id"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type ChirpLike struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID `json:"chirp_id"`
+	UserID      uuid.UUID `json:"user_id"`
+	StartOffset int32     `json:"start_offset"`
+	EndOffset   int32     `json:"end_offset"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	AuthoredAt time.Time `json:"authored_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	TagID     uuid.UUID `json:"tag_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Chirp struct {
+	ID            uuid.UUID     `json:"id"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Body          string        `json:"body"`
+	UserID        uuid.UUID     `json:"user_id"`
+	InReplyToID   uuid.NullUUID `json:"in_reply_to_id"`
+	ThreadID      uuid.NullUUID `json:"thread_id"`
+	QuotedChirpID uuid.NullUUID `json:"quoted_chirp_id"`
+	IsRechirp     bool          `json:"is_rechirp"`
+	SearchVector  interface{}   `json:"search_vector"`
+	HiddenAt      sql.NullTime  `json:"hidden_at"`
+}
+
+type Draft struct {
+	ID              uuid.UUID     `json:"id"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	UserID          uuid.UUID     `json:"user_id"`
+	Body            string        `json:"body"`
+	InReplyToID     uuid.NullUUID `json:"in_reply_to_id"`
+	RechirpOfID     uuid.NullUUID `json:"rechirp_of_id"`
+	QuoteOfID       uuid.NullUUID `json:"quote_of_id"`
+	MediaIds        []uuid.UUID   `json:"media_ids"`
+	PollOptions     []string      `json:"poll_options"`
+	PollClosesAt    sql.NullTime  `json:"poll_closes_at"`
+	PublishAt       sql.NullTime  `json:"publish_at"`
+	PublishError    string        `json:"publish_error"`
+	PublishAttempts int32         `json:"publish_attempts"`
+}
+
+type Follow struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type MediaAttachment struct {
+	ID                   uuid.UUID     `json:"id"`
+	CreatedAt            time.Time     `json:"created_at"`
+	UserID               uuid.UUID     `json:"user_id"`
+	ChirpID              uuid.NullUUID `json:"chirp_id"`
+	Position             sql.NullInt32 `json:"position"`
+	ContentType          string        `json:"content_type"`
+	SizeBytes            int64         `json:"size_bytes"`
+	Width                int32         `json:"width"`
+	Height               int32         `json:"height"`
+	AltText              string        `json:"alt_text"`
+	StorageKey           string        `json:"storage_key"`
+	ThumbnailKey         string        `json:"thumbnail_key"`
+	ThumbnailContentType string        `json:"thumbnail_content_type"`
+}
+
+type Mute struct {
+	MuterID   uuid.UUID `json:"muter_id"`
+	MutedID   uuid.UUID `json:"muted_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PollOption struct {
+	ID        uuid.UUID `json:"id"`
+	PollID    uuid.UUID `json:"poll_id"`
+	Position  int32     `json:"position"`
+	Text      string    `json:"text"`
+	VoteCount int64     `json:"vote_count"`
+}
+
+type PollVote struct {
+	PollID    uuid.UUID `json:"poll_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	OptionID  uuid.UUID `json:"option_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Poll struct {
+	ID        uuid.UUID    `json:"id"`
 	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
-	UserID    uuid.UUID    `json:"user_id"`
-	ExpiresAt time.Time    `json:"expires_at"`
-	RevokedAt sql.NullTime `json:"revoked_at"`
+	ChirpID   uuid.UUID    `json:"chirp_id"`
+	ClosesAt  time.Time    `json:"closes_at"`
+	ClosedAt  sql.NullTime `json:"closed_at"`
+}
+
+type RefreshToken struct {
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	UserID       uuid.UUID    `json:"user_id"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+	RevokedAt    sql.NullTime `json:"revoked_at"`
+	FamilyID     uuid.UUID    `json:"family_id"`
+	LookupPrefix string       `json:"lookup_prefix"`
+	TokenHash    string       `json:"token_hash"`
+}
+
+type ReportAction struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	ReportID  uuid.UUID     `json:"report_id"`
+	AdminID   uuid.NullUUID `json:"admin_id"`
+	Action    string        `json:"action"`
+	Note      string        `json:"note"`
+}
+
+type Report struct {
+	ID             uuid.UUID      `json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	ReporterID     uuid.NullUUID  `json:"reporter_id"`
+	ReportedUserID uuid.UUID      `json:"reported_user_id"`
+	ChirpID        uuid.NullUUID  `json:"chirp_id"`
+	Reason         string         `json:"reason"`
+	Details        string         `json:"details"`
+	Status         string         `json:"status"`
+	ClaimedBy      uuid.NullUUID  `json:"claimed_by"`
+	ClaimedAt      sql.NullTime   `json:"claimed_at"`
+	Resolution     sql.NullString `json:"resolution"`
+	ResolvedAt     sql.NullTime   `json:"resolved_at"`
+}
+
+type Session struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	UserID      uuid.UUID `json:"user_id"`
+	UserAgent   string    `json:"user_agent"`
+	IpAddress   string    `json:"ip_address"`
+	DeviceLabel string    `json:"device_label"`
+}
+
+type Tag struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+}
+
+type UserRole struct {
+	UserID    uuid.UUID     `json:"user_id"`
+	Role      string        `json:"role"`
+	GrantedBy uuid.NullUUID `json:"granted_by"`
+	CreatedAt time.Time     `json:"created_at"`
 }
 
 type User struct {
-	ID             uuid.UUID `json:"id"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	Email          string    `json:"email"`
-	HashedPassword string    `json:"hashed_password"`
-	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	ID             uuid.UUID     `json:"id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Email          string        `json:"email"`
+	HashedPassword string        `json:"hashed_password"`
+	IsChirpyRed    bool          `json:"is_chirpy_red"`
+	Handle         string        `json:"handle"`
+	DisplayName    string        `json:"display_name"`
+	Bio            string        `json:"bio"`
+	AvatarUrl      string        `json:"avatar_url"`
+	SuspendedAt    sql.NullTime  `json:"suspended_at"`
+	AvatarKey      string        `json:"avatar_key"`
+	PinnedChirpID  uuid.NullUUID `json:"pinned_chirp_id"`
 }
@@ -8,42 +8,54 @@ package database
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
-VALUES ($1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $2, CURRENT_TIMESTAMP + INTERVAL '60 day', NULL)
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+INSERT INTO refresh_tokens (lookup_prefix, token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id)
+VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $3, $5, NULL, $4)
+RETURNING created_at, updated_at, user_id, expires_at, revoked_at, family_id, lookup_prefix, token_hash
 `
 
 type CreateRefreshTokenParams struct {
-	Token  string    `json:"token"`
-	UserID uuid.UUID `json:"user_id"`
+	LookupPrefix string    `json:"lookup_prefix"`
+	TokenHash    string    `json:"token_hash"`
+	UserID       uuid.UUID `json:"user_id"`
+	FamilyID     uuid.UUID `json:"family_id"`
+	ExpiresAt    time.Time `json:"expires_at"`
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.LookupPrefix,
+		arg.TokenHash,
+		arg.UserID,
+		arg.FamilyID,
+		arg.ExpiresAt,
+	)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.LookupPrefix,
+		&i.TokenHash,
 	)
 	return i, err
 }
 
 const deleteRefreshToken = `-- name: DeleteRefreshToken :exec
 DELETE FROM refresh_tokens
-WHERE token = $1
+WHERE token_hash = $1
 `
 
-func (q *Queries) DeleteRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, deleteRefreshToken, token)
+func (q *Queries) DeleteRefreshToken(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, deleteRefreshToken, tokenHash)
 	return err
 }
 
@@ -56,30 +68,37 @@ func (q *Queries) DeleteRefreshTokens(ctx context.Context) error {
 	return err
 }
 
-const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at
+const getRefreshTokenForUpdate = `-- name: GetRefreshTokenForUpdate :one
+SELECT created_at, updated_at, user_id, expires_at, revoked_at, family_id, lookup_prefix, token_hash
 FROM refresh_tokens
-WHERE token = $1
-  AND CURRENT_TIMESTAMP < expires_at
-  AND revoked_at IS NULL
+WHERE lookup_prefix = $1
+  AND token_hash = $2
+FOR UPDATE
 `
 
-func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
+type GetRefreshTokenForUpdateParams struct {
+	LookupPrefix string `json:"lookup_prefix"`
+	TokenHash    string `json:"token_hash"`
+}
+
+func (q *Queries) GetRefreshTokenForUpdate(ctx context.Context, arg GetRefreshTokenForUpdateParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshTokenForUpdate, arg.LookupPrefix, arg.TokenHash)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.LookupPrefix,
+		&i.TokenHash,
 	)
 	return i, err
 }
 
 const getRefreshTokens = `-- name: GetRefreshTokens :many
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at
+SELECT created_at, updated_at, user_id, expires_at, revoked_at, family_id, lookup_prefix, token_hash
 FROM refresh_tokens
 WHERE CURRENT_TIMESTAMP < expires_at
   AND revoked_at IS NULL
@@ -96,12 +115,14 @@ func (q *Queries) GetRefreshTokens(ctx context.Context) ([]RefreshToken, error)
 	for rows.Next() {
 		var i RefreshToken
 		if err := rows.Scan(
-			&i.Token,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.ExpiresAt,
 			&i.RevokedAt,
+			&i.FamilyID,
+			&i.LookupPrefix,
+			&i.TokenHash,
 		); err != nil {
 			return nil, err
 		}
@@ -121,13 +142,47 @@ UPDATE refresh_tokens
 SET
   revoked_at = CURRENT_TIMESTAMP,
   updated_at = CURRENT_TIMESTAMP
-WHERE token = $1
+WHERE lookup_prefix = $1
+  AND token_hash = $2
 RETURNING revoked_at
 `
 
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) (sql.NullTime, error) {
-	row := q.db.QueryRowContext(ctx, revokeRefreshToken, token)
+type RevokeRefreshTokenParams struct {
+	LookupPrefix string `json:"lookup_prefix"`
+	TokenHash    string `json:"token_hash"`
+}
+
+func (q *Queries) RevokeRefreshToken(ctx context.Context, arg RevokeRefreshTokenParams) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, revokeRefreshToken, arg.LookupPrefix, arg.TokenHash)
 	var revoked_at sql.NullTime
 	err := row.Scan(&revoked_at)
 	return revoked_at, err
 }
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET
+  revoked_at = CURRENT_TIMESTAMP,
+  updated_at = CURRENT_TIMESTAMP
+WHERE family_id = $1
+  AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET
+  revoked_at = CURRENT_TIMESTAMP,
+  updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $1
+  AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
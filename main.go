@@ -2,23 +2,46 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/mmycroft/boot-dev-chirpy/api"
+	"github.com/mmycroft/boot-dev-chirpy/auth"
 	"github.com/mmycroft/boot-dev-chirpy/database"
+	"github.com/mmycroft/boot-dev-chirpy/moderation"
+	"github.com/mmycroft/boot-dev-chirpy/storage"
 )
 
 const (
 	_ROOT = "./"
 	_PORT = 8080
+
+	_DEFAULT_CHIRP_EDIT_WINDOW      = 15 * time.Minute
+	_DEFAULT_TRENDING_WINDOW        = 24 * time.Hour
+	_DEFAULT_TRENDING_HALF_LIFE     = 6 * time.Hour
+	_DEFAULT_POLL_CLOSE_INTERVAL    = time.Minute
+	_DEFAULT_DRAFT_PUBLISH_INTERVAL = 30 * time.Second
+	_DEFAULT_MEDIA_CLEANUP_INTERVAL = time.Hour
+	_DEFAULT_UNATTACHED_MEDIA_AGE   = 24 * time.Hour
+	_DEFAULT_ACCESS_TOKEN_LIFETIME  = time.Hour
+	_DEFAULT_REFRESH_TOKEN_LIFETIME = 60 * 24 * time.Hour
+
+	_DEFAULT_MODERATION_WORDS = "moderation/words.txt"
+
+	// local uploads sit under _ROOT so the /app/ file server serves them
+	_DEFAULT_STORAGE_DIR      = "uploads"
+	_DEFAULT_STORAGE_BASE_URL = "/app/uploads"
 )
 
 func main() {
@@ -28,7 +51,38 @@ func main() {
 
 	dbURL := os.Getenv("DB_URL")
 	platform := os.Getenv("PLATFORM")
-	secret := os.Getenv("SECRET")
+
+	chirpEditWindow := durationEnv("CHIRP_EDIT_WINDOW", _DEFAULT_CHIRP_EDIT_WINDOW)
+	trendingWindow := durationEnv("TRENDING_WINDOW", _DEFAULT_TRENDING_WINDOW)
+	trendingHalfLife := durationEnv("TRENDING_HALF_LIFE", _DEFAULT_TRENDING_HALF_LIFE)
+	pollCloseInterval := durationEnv("POLL_CLOSE_INTERVAL", _DEFAULT_POLL_CLOSE_INTERVAL)
+	draftPublishInterval := durationEnv("DRAFT_PUBLISH_INTERVAL", _DEFAULT_DRAFT_PUBLISH_INTERVAL)
+	mediaCleanupInterval := durationEnv("MEDIA_CLEANUP_INTERVAL", _DEFAULT_MEDIA_CLEANUP_INTERVAL)
+	unattachedMediaAge := durationEnv("UNATTACHED_MEDIA_AGE", _DEFAULT_UNATTACHED_MEDIA_AGE)
+	accessTokenLifetime := durationEnv("ACCESS_TOKEN_LIFETIME", _DEFAULT_ACCESS_TOKEN_LIFETIME)
+	refreshTokenLifetime := durationEnv("REFRESH_TOKEN_LIFETIME", _DEFAULT_REFRESH_TOKEN_LIFETIME)
+
+	moderationWords := os.Getenv("MODERATION_WORDS")
+	if moderationWords == "" {
+		moderationWords = _DEFAULT_MODERATION_WORDS
+	}
+
+	wordList, err := moderation.LoadWordList(moderationWords)
+	if err != nil {
+		log.Fatal(err)
+	}
+	moderator := moderation.NewPipeline(wordList)
+	go reloadOnHangup(moderator)
+
+	store, err := newStorage()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keys, err := newKeyring()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	db, err := sql.Open("postgres", dbURL)
 	if err != nil {
@@ -43,25 +97,53 @@ func main() {
 	}
 
 	cfg := &api.APIConfig{
-		FileServerHits: atomic.Int32{},
-		DBQueries:      dbQueries,
-		Templates:      templates,
-		Platform:       platform,
-		Secret:         secret,
+		FileServerHits:       atomic.Int32{},
+		DB:                   db,
+		DBQueries:            dbQueries,
+		Templates:            templates,
+		Platform:             platform,
+		Keys:                 keys,
+		AccessTokenLifetime:  accessTokenLifetime,
+		RefreshTokenLifetime: refreshTokenLifetime,
+		ChirpEditWindow:      chirpEditWindow,
+		TrendingWindow:       trendingWindow,
+		TrendingHalfLife:     trendingHalfLife,
+		Moderator:            moderator,
+		Storage:              store,
 	}
 
+	go cfg.RunPollCloser(context.Background(), pollCloseInterval)
+	go cfg.RunDraftPublisher(context.Background(), draftPublishInterval)
+	go cfg.RunMediaCleanup(context.Background(), mediaCleanupInterval, unattachedMediaAge)
+
 	mux := http.NewServeMux()
 
 	mux.Handle("/app/", cfg.MiddlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(_ROOT)))))
 
-	mux.HandleFunc("GET /admin/metrics", cfg.HandlerNumRequests)
-	mux.HandleFunc("POST /admin/reset", cfg.HandlerResetNumRequests)
+	// every /admin/ route needs a role, reset additionally only works when PLATFORM is dev
+	adminsOnly := cfg.MiddlewareRequireRoles(api.RoleAdmin)
+	moderatorsOnly := cfg.MiddlewareRequireRoles(api.RoleAdmin, api.RoleModerator)
+
+	mux.Handle("GET /admin/metrics", adminsOnly(http.HandlerFunc(cfg.HandlerNumRequests)))
+	mux.Handle("POST /admin/reset", adminsOnly(http.HandlerFunc(cfg.HandlerResetNumRequests)))
+	mux.Handle("POST /admin/moderation/reload", adminsOnly(http.HandlerFunc(cfg.HandlerReloadModeration)))
+	mux.Handle("GET /admin/users/{userID}/roles", adminsOnly(http.HandlerFunc(cfg.HandlerGetUserRoles)))
+	mux.Handle("PUT /admin/users/{userID}/roles/{role}", adminsOnly(http.HandlerFunc(cfg.HandlerGrantRole)))
+	mux.Handle("DELETE /admin/users/{userID}/roles/{role}", adminsOnly(http.HandlerFunc(cfg.HandlerRevokeRole)))
+	mux.Handle("GET /admin/reports", moderatorsOnly(http.HandlerFunc(cfg.HandlerGetReports)))
+	mux.Handle("GET /admin/reports/{reportID}", moderatorsOnly(http.HandlerFunc(cfg.HandlerGetReport)))
+	mux.Handle("POST /admin/reports/{reportID}/claim", moderatorsOnly(http.HandlerFunc(cfg.HandlerClaimReport)))
+	mux.Handle("POST /admin/reports/{reportID}/resolve", moderatorsOnly(http.HandlerFunc(cfg.HandlerResolveReport)))
 
 	mux.HandleFunc("GET /api/healthz", cfg.HandlerReadiness)
+	mux.HandleFunc("GET /.well-known/jwks.json", cfg.HandlerJWKS)
 
 	mux.HandleFunc("POST /api/login", cfg.HandlerLogin)
 	mux.HandleFunc("POST /api/refresh", cfg.HandlerRefresh)
 	mux.HandleFunc("POST /api/revoke", cfg.HandlerRevoke)
+	mux.HandleFunc("GET /api/sessions", cfg.HandlerGetSessions)
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", cfg.HandlerRevokeSession)
+	mux.HandleFunc("POST /api/sessions/revoke-all", cfg.HandlerRevokeAllSessions)
 
 	mux.HandleFunc("POST /api/polka/webhooks", cfg.HandlerUpgradeUser)
 	mux.HandleFunc("POST /api/users", cfg.HandlerCreateUser)
@@ -69,16 +151,171 @@ func main() {
 	mux.HandleFunc("GET /api/users/{userID}", cfg.HandlerGetUser)
 	mux.HandleFunc("PUT /api/users", cfg.HandlerUpdateUser)
 
+	mux.HandleFunc("POST /api/users/{userID}/follow", cfg.HandlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.HandlerUnfollowUser)
+	mux.HandleFunc("POST /api/users/{userID}/report", cfg.HandlerReportUser)
+	mux.HandleFunc("POST /api/users/{userID}/block", cfg.HandlerBlockUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", cfg.HandlerUnblockUser)
+	mux.HandleFunc("POST /api/users/{userID}/mute", cfg.HandlerMuteUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", cfg.HandlerUnmuteUser)
+	// followers, following, likes, identicon and by-handle/{handle}
+	mux.HandleFunc("GET /api/users/{userID}/{collection}", cfg.HandlerGetUserCollection)
+	mux.HandleFunc("PUT /api/users/me/avatar", cfg.HandlerSetAvatar)
+	mux.HandleFunc("DELETE /api/users/me/avatar", cfg.HandlerDeleteAvatar)
+	mux.HandleFunc("GET /api/users/me/mentions", cfg.HandlerGetMyMentions)
+	mux.HandleFunc("GET /api/users/me/blocks", cfg.HandlerGetMyBlocks)
+	mux.HandleFunc("GET /api/users/me/mutes", cfg.HandlerGetMyMutes)
+	mux.HandleFunc("GET /api/users/me/bookmarks", cfg.HandlerGetMyBookmarks)
+	mux.HandleFunc("GET /api/users/me/collections", cfg.HandlerGetBookmarkCollections)
+	mux.HandleFunc("POST /api/users/me/collections", cfg.HandlerCreateBookmarkCollection)
+	mux.HandleFunc("PATCH /api/users/me/collections/{collectionID}", cfg.HandlerRenameBookmarkCollection)
+	mux.HandleFunc("DELETE /api/users/me/collections/{collectionID}", cfg.HandlerDeleteBookmarkCollection)
+	mux.HandleFunc("GET /api/timeline", cfg.HandlerGetTimeline)
+
+	mux.HandleFunc("GET /api/search/chirps", cfg.HandlerSearchChirps)
+
+	mux.HandleFunc("GET /api/tags/trending", cfg.HandlerGetTrendingTags)
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", cfg.HandlerGetTagChirps)
+
+	mux.HandleFunc("POST /api/media", cfg.HandlerUploadMedia)
+
+	mux.HandleFunc("POST /api/drafts", cfg.HandlerCreateDraft)
+	mux.HandleFunc("GET /api/drafts", cfg.HandlerGetDrafts)
+	mux.HandleFunc("GET /api/drafts/{draftID}", cfg.HandlerGetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", cfg.HandlerUpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", cfg.HandlerDeleteDraft)
+
 	mux.HandleFunc("POST /api/chirps", cfg.HandlerCreateChirp)
 	mux.HandleFunc("GET /api/chirps", cfg.HandlerGetChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.HandlerGetChirp)
+	mux.HandleFunc("PATCH /api/chirps/{chirpID}", cfg.HandlerUpdateChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.HandlerDeleteChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/history", cfg.HandlerGetChirpHistory)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", cfg.HandlerGetThread)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/like", cfg.HandlerLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", cfg.HandlerUnlikeChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/vote", cfg.HandlerVotePoll)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/bookmark", cfg.HandlerBookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.HandlerUnbookmarkChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/pin", cfg.HandlerPinChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", cfg.HandlerUnpinChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/report", cfg.HandlerReportChirp)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", _PORT),
-		Handler: mux,
+		Handler: cfg.MiddlewareClaims(mux),
 	}
 
 	log.Printf("Serving files from %s on port: %d\n", _ROOT, _PORT)
 	log.Fatal(server.ListenAndServe())
 }
+
+// durationEnv reads a positive time.Duration such as "15m" from the
+// environment, returning fallback when the variable is unset
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	d := nonNegativeDurationEnv(key, fallback)
+	if d == 0 {
+		log.Fatalf("%s must be positive, got %v", key, d)
+	}
+
+	return d
+}
+
+// nonNegativeDurationEnv is durationEnv for settings where zero means
+// "none", such as JWT_LEEWAY
+func nonNegativeDurationEnv(key string, fallback time.Duration) time.Duration {
+	s := os.Getenv(key)
+	if s == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		log.Fatalf("error parsing %s: %v", key, err)
+	}
+	if d < 0 {
+		log.Fatalf("%s must not be negative, got %v", key, d)
+	}
+
+	return d
+}
+
+// newKeyring loads the keys access tokens are signed with. SECRET, if set,
+// is an HMAC key with kid "default". JWT_KEYS_DIR can hold more keys, see
+// auth.Keyring.LoadDir, and JWT_SIGNING_KEY names the one new tokens are
+// signed with, otherwise the first one loaded. JWT_AUDIENCE and JWT_LEEWAY
+// override auth.DefaultAudience and auth.DefaultLeeway.
+func newKeyring() (*auth.Keyring, error) {
+	keys := auth.NewKeyring()
+	keys.Leeway = nonNegativeDurationEnv("JWT_LEEWAY", auth.DefaultLeeway)
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		keys.Audience = audience
+	}
+
+	if secret := os.Getenv("SECRET"); secret != "" {
+		if err := keys.AddHMAC(auth.DefaultKeyID, []byte(secret)); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		if err := keys.LoadDir(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if kid := os.Getenv("JWT_SIGNING_KEY"); kid != "" {
+		if err := keys.SetSigningKey(kid); err != nil {
+			return nil, err
+		}
+	}
+
+	if keys.SigningKeyID() == "" {
+		return nil, fmt.Errorf("no signing key, set SECRET or JWT_KEYS_DIR")
+	}
+
+	return keys, nil
+}
+
+// newStorage picks where uploads are kept from STORAGE, "local" (the
+// default) or "s3" for any S3 compatible service such as MinIO
+func newStorage() (storage.Storage, error) {
+	switch backend := os.Getenv("STORAGE"); backend {
+	case "", "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = _DEFAULT_STORAGE_DIR
+		}
+		baseURL := os.Getenv("STORAGE_BASE_URL")
+		if baseURL == "" {
+			baseURL = _DEFAULT_STORAGE_BASE_URL
+		}
+		return storage.NewLocal(dir, baseURL)
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE %q, must be local or s3", backend)
+	}
+}
+
+// reloadOnHangup reloads the moderation rules whenever the process gets
+// SIGHUP, keeping the old rules if the new ones fail to load
+func reloadOnHangup(moderator *moderation.Pipeline) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	for range hangup {
+		if err := moderator.Reload(); err != nil {
+			log.Printf("error reloading moderation rules: %v\n", err)
+			continue
+		}
+		log.Println("reloaded moderation rules")
+	}
+}
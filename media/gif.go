@@ -0,0 +1,65 @@
+package media
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// gifFramePixels walks the blocks of a GIF without decoding any of them and
+// returns how many frames it has and the sum of their areas, which is what
+// gif.DecodeAll would allocate
+func gifFramePixels(data []byte) (int, int, error) {
+	errTruncated := fmt.Errorf("gif is truncated")
+
+	// header and logical screen descriptor
+	if len(data) < 13 {
+		return 0, 0, errTruncated
+	}
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+
+	frames, pixels := 0, 0
+	for {
+		if i >= len(data) {
+			return 0, 0, errTruncated
+		}
+
+		switch data[i] {
+		case 0x21: // extension: label, then sub-blocks
+			i += 2
+		case 0x2C: // image descriptor, then an optional color table, the LZW code size and sub-blocks
+			if i+10 > len(data) {
+				return 0, 0, errTruncated
+			}
+			width := int(binary.LittleEndian.Uint16(data[i+5:]))
+			height := int(binary.LittleEndian.Uint16(data[i+7:]))
+			frames++
+			pixels += width * height
+
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			i++
+		case 0x3B: // trailer
+			return frames, pixels, nil
+		default:
+			return 0, 0, fmt.Errorf("gif has an unknown block %#x", data[i])
+		}
+
+		// sub-blocks are length prefixed and end with an empty one
+		for {
+			if i >= len(data) {
+				return 0, 0, errTruncated
+			}
+			size := int(data[i])
+			i += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+}
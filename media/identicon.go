@@ -0,0 +1,57 @@
+package media
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+)
+
+// _IDENTICON_CELLS is the width and height of an identicon's grid
+const _IDENTICON_CELLS = 5
+
+var identiconBackground = color.RGBA{240, 240, 240, 255}
+
+// Identicon draws a size x size picture that is unique enough to tell users
+// apart: a left-right symmetric 5x5 grid of cells in one color, picked from
+// a hash of seed. The same seed always gives the same picture.
+func Identicon(seed []byte, size int) *image.RGBA {
+	sum := sha256.Sum256(seed)
+
+	// keep the color away from the background so light picks still show
+	fg := color.RGBA{sum[0]/2 + 32, sum[1]/2 + 32, sum[2]/2 + 32, 255}
+
+	// the left three columns come from the hash, the right two mirror them
+	var cells [_IDENTICON_CELLS][_IDENTICON_CELLS]bool
+	bit := 0
+	for x := 0; x < (_IDENTICON_CELLS+1)/2; x++ {
+		for y := 0; y < _IDENTICON_CELLS; y++ {
+			on := sum[3+bit/8]&(1<<(bit%8)) != 0
+			cells[y][x] = on
+			cells[y][_IDENTICON_CELLS-1-x] = on
+			bit++
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	// a margin of half a cell on every side
+	cell := float64(size) / (_IDENTICON_CELLS + 1)
+	margin := cell / 2
+
+	for py := 0; py < size; py++ {
+		for px := 0; px < size; px++ {
+			c := identiconBackground
+
+			x := int((float64(px) - margin) / cell)
+			y := int((float64(py) - margin) / cell)
+			if float64(px) >= margin && float64(py) >= margin &&
+				x < _IDENTICON_CELLS && y < _IDENTICON_CELLS && cells[y][x] {
+				c = fg
+			}
+
+			img.SetRGBA(px, py, c)
+		}
+	}
+
+	return img
+}
@@ -0,0 +1,182 @@
+// Package media validates uploaded images and prepares them for serving
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxSize is the largest upload accepted, in bytes
+	MaxSize = 5 << 20
+	// MaxPixels bounds width*height so a small file cannot decode into a
+	// huge image
+	MaxPixels = 40_000_000
+	// MaxFrames bounds the frames of an animated GIF, whose areas also count
+	// towards MaxPixels together
+	MaxFrames = 500
+
+	_JPEG_QUALITY = 90
+)
+
+// ErrUnsupportedType is returned for uploads that are not a JPEG, PNG or GIF
+var ErrUnsupportedType = errors.New("unsupported media type, must be a JPEG, PNG or GIF")
+
+// Image is an upload that passed validation. Data holds the image
+// re-encoded from its pixels, which drops EXIF and any other metadata the
+// original carried.
+type Image struct {
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+	// Image is the decoded first frame, for making thumbnails
+	Image image.Image
+}
+
+// Extension returns the file extension for the image's content type
+func (img *Image) Extension() string {
+	return Extension(img.ContentType)
+}
+
+// Extension returns the file extension for a content type Process accepts
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	default:
+		return ""
+	}
+}
+
+// Process checks that data is a JPEG, PNG or GIF of a sensible size and
+// re-encodes it. JPEGs are turned upright according to their EXIF
+// orientation first, since that tag goes away with the rest of the metadata.
+// Animated GIFs keep all their frames.
+func Process(data []byte) (*Image, error) {
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("media is too large, must be %d bytes or less", MaxSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	if Extension(contentType) == "" {
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error reading image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("image dimensions %dx%d are not allowed", config.Width, config.Height)
+	}
+
+	var buf bytes.Buffer
+	var decoded image.Image
+
+	switch contentType {
+	case "image/jpeg":
+		decoded, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding jpeg: %w", err)
+		}
+		decoded = Orient(decoded, jpegOrientation(data))
+		err = jpeg.Encode(&buf, decoded, &jpeg.Options{Quality: _JPEG_QUALITY})
+	case "image/png":
+		decoded, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding png: %w", err)
+		}
+		err = png.Encode(&buf, decoded)
+	case "image/gif":
+		// DecodeAll allocates every frame, which DecodeConfig does not count
+		frames, pixels, frameErr := gifFramePixels(data)
+		if frameErr != nil {
+			return nil, fmt.Errorf("error reading gif: %w", frameErr)
+		}
+		if frames > MaxFrames || pixels > MaxPixels {
+			return nil, fmt.Errorf("gif with %d frames of %d pixels is not allowed", frames, pixels)
+		}
+
+		var anim *gif.GIF
+		anim, err = gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding gif: %w", err)
+		}
+		decoded = anim.Image[0]
+		// only the frames, timing and disposal are carried over
+		err = gif.EncodeAll(&buf, &gif.GIF{
+			Image:           anim.Image,
+			Delay:           anim.Delay,
+			LoopCount:       anim.LoopCount,
+			Disposal:        anim.Disposal,
+			Config:          anim.Config,
+			BackgroundIndex: anim.BackgroundIndex,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error encoding image: %w", err)
+	}
+
+	bounds := decoded.Bounds()
+
+	return &Image{
+		ContentType: contentType,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Data:        buf.Bytes(),
+		Image:       decoded,
+	}, nil
+}
+
+// Thumbnail scales img down to fit within a maxEdge square, keeping its
+// aspect ratio. Images that already fit are returned unscaled.
+func Thumbnail(img image.Image, maxEdge int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxEdge && height <= maxEdge {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*maxEdge/width)
+		width = maxEdge
+	} else {
+		width = max(1, width*maxEdge/height)
+		height = maxEdge
+	}
+
+	return Resize(img, width, height)
+}
+
+// Encode encodes img as contentType. GIFs become PNGs since a thumbnail is
+// a single frame, so the content type actually used is returned.
+func Encode(img image.Image, contentType string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: _JPEG_QUALITY})
+	case "image/png", "image/gif":
+		contentType = "image/png"
+		err = png.Encode(&buf, img)
+	default:
+		return nil, "", ErrUnsupportedType
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error encoding image: %w", err)
+	}
+
+	return buf.Bytes(), contentType, nil
+}
@@ -0,0 +1,261 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+		}
+	}
+	// a red top left corner to tell orientations apart
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	return img
+}
+
+// withOrientation inserts an EXIF APP1 segment holding orientation right
+// after the SOI marker of a JPEG
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, _EXIF_ORIENTATION)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, app1...)
+	return append(out, jpg[2:]...)
+}
+
+// animatedGIF encodes a GIF of frames copies of a width x height image
+func animatedGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+
+	frame := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+	anim := &gif.GIF{}
+	for range frames {
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	var pngBuf, jpgBuf, gifBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, testImage(40, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpgBuf, testImage(40, 20), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gifBuf, testImage(40, 20), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		data            []byte
+		wantContentType string
+		wantWidth       int
+		wantHeight      int
+		wantErr         bool
+	}{
+		{
+			name:            "PNG",
+			data:            pngBuf.Bytes(),
+			wantContentType: "image/png",
+			wantWidth:       40,
+			wantHeight:      20,
+		},
+		{
+			name:            "JPEG",
+			data:            jpgBuf.Bytes(),
+			wantContentType: "image/jpeg",
+			wantWidth:       40,
+			wantHeight:      20,
+		},
+		{
+			name:            "JPEG rotated by EXIF",
+			data:            withOrientation(jpgBuf.Bytes(), 6),
+			wantContentType: "image/jpeg",
+			wantWidth:       20,
+			wantHeight:      40,
+		},
+		{
+			name:            "GIF",
+			data:            gifBuf.Bytes(),
+			wantContentType: "image/gif",
+			wantWidth:       40,
+			wantHeight:      20,
+		},
+		{
+			name:            "Animated GIF",
+			data:            animatedGIF(t, 40, 20, 3),
+			wantContentType: "image/gif",
+			wantWidth:       40,
+			wantHeight:      20,
+		},
+		{
+			name:    "GIF with too many frames",
+			data:    animatedGIF(t, 4, 4, MaxFrames+1),
+			wantErr: true,
+		},
+		{
+			name:    "Text",
+			data:    []byte("just a chirp"),
+			wantErr: true,
+		},
+		{
+			name:    "Truncated PNG",
+			data:    pngBuf.Bytes()[:30],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Process(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if img.ContentType != tt.wantContentType || img.Width != tt.wantWidth || img.Height != tt.wantHeight {
+				t.Errorf("Process() = %s %dx%d, want %s %dx%d",
+					img.ContentType, img.Width, img.Height, tt.wantContentType, tt.wantWidth, tt.wantHeight)
+			}
+
+			if bytes.Contains(img.Data, []byte("Exif")) {
+				t.Error("Process() kept EXIF metadata")
+			}
+
+			config, _, err := image.DecodeConfig(bytes.NewReader(img.Data))
+			if err != nil || config.Width != tt.wantWidth || config.Height != tt.wantHeight {
+				t.Errorf("re-encoded image = %dx%d, %v, want %dx%d", config.Width, config.Height, err, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	img := testImage(3, 2)
+
+	tests := []struct {
+		orientation int
+		wantSize    image.Point
+		// where the red top left corner ends up
+		wantCorner image.Point
+	}{
+		{1, image.Pt(3, 2), image.Pt(0, 0)},
+		{2, image.Pt(3, 2), image.Pt(2, 0)},
+		{3, image.Pt(3, 2), image.Pt(2, 1)},
+		{4, image.Pt(3, 2), image.Pt(0, 1)},
+		{5, image.Pt(2, 3), image.Pt(0, 0)},
+		{6, image.Pt(2, 3), image.Pt(1, 0)},
+		{7, image.Pt(2, 3), image.Pt(1, 2)},
+		{8, image.Pt(2, 3), image.Pt(0, 2)},
+	}
+
+	for _, tt := range tests {
+		oriented := Orient(img, tt.orientation)
+		if size := oriented.Bounds().Size(); size != tt.wantSize {
+			t.Errorf("Orient(%d) size = %v, want %v", tt.orientation, size, tt.wantSize)
+			continue
+		}
+		if r, _, _, _ := oriented.At(tt.wantCorner.X, tt.wantCorner.Y).RGBA(); r>>8 != 255 {
+			t.Errorf("Orient(%d) corner not at %v", tt.orientation, tt.wantCorner)
+		}
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     image.Point
+		maxEdge  int
+		wantSize image.Point
+	}{
+		{"Landscape", image.Pt(800, 400), 200, image.Pt(200, 100)},
+		{"Portrait", image.Pt(300, 900), 300, image.Pt(100, 300)},
+		{"Already small", image.Pt(50, 40), 200, image.Pt(50, 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb := Thumbnail(testImage(tt.size.X, tt.size.Y), tt.maxEdge)
+			if size := thumb.Bounds().Size(); size != tt.wantSize {
+				t.Errorf("Thumbnail() size = %v, want %v", size, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestCropSquare(t *testing.T) {
+	square := CropSquare(testImage(40, 20))
+	if size := square.Bounds().Size(); size != image.Pt(20, 20) {
+		t.Fatalf("CropSquare() size = %v, want 20x20", size)
+	}
+	// the crop starts 10 pixels in, and the test image's red channel is x
+	if r, _, _, _ := square.At(0, 5).RGBA(); r>>8 != 10 {
+		t.Errorf("CropSquare() left edge red = %d, want 10", r>>8)
+	}
+}
+
+func TestIdenticon(t *testing.T) {
+	a := Identicon([]byte("user-a"), 48)
+	if size := a.Bounds().Size(); size != image.Pt(48, 48) {
+		t.Fatalf("Identicon() size = %v, want 48x48", size)
+	}
+
+	if again := Identicon([]byte("user-a"), 48); !bytes.Equal(a.Pix, again.Pix) {
+		t.Error("Identicon() is not deterministic")
+	}
+
+	if b := Identicon([]byte("user-b"), 48); bytes.Equal(a.Pix, b.Pix) {
+		t.Error("Identicon() gave two seeds the same picture")
+	}
+
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 24; x++ {
+			if a.RGBAAt(x, y) != a.RGBAAt(47-x, y) {
+				t.Fatalf("Identicon() is not symmetric at %d,%d", x, y)
+			}
+		}
+	}
+}
+
+func TestGIFFramePixels(t *testing.T) {
+	frames, pixels, err := gifFramePixels(animatedGIF(t, 40, 20, 3))
+	if err != nil || frames != 3 || pixels != 3*40*20 {
+		t.Errorf("gifFramePixels() = %d, %d, %v, want 3, %d, nil", frames, pixels, err, 3*40*20)
+	}
+
+	if _, _, err := gifFramePixels(animatedGIF(t, 40, 20, 3)[:40]); err == nil {
+		t.Error("gifFramePixels() of a truncated gif expected error, got nil")
+	}
+}
@@ -0,0 +1,178 @@
+// Package moderation holds the content moderation pipeline chirps go through
+package moderation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// _MASK replaces every masked word, whatever its length
+const _MASK = "****"
+
+// Action is what happens to text that matches a moderation rule
+type Action string
+
+const (
+	// ActionMask replaces the matched word with ****
+	ActionMask Action = "mask"
+	// ActionReject refuses the text outright
+	ActionReject Action = "reject"
+	// ActionFlag lets the text through but marks it for review
+	ActionFlag Action = "flag"
+)
+
+// ParseAction parses the action name used in word lists
+func ParseAction(s string) (Action, error) {
+	switch action := Action(strings.ToLower(s)); action {
+	case ActionMask, ActionReject, ActionFlag:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown moderation action %q", s)
+	}
+}
+
+// Match is a rule hit in a text. Start and End are code point offsets of
+// the matched word, End exclusive.
+type Match struct {
+	Word   string
+	Action Action
+	Start  int
+	End    int
+}
+
+// Filter finds the parts of a text that break its rules
+type Filter interface {
+	Match(text string) []Match
+}
+
+// Reloader is a Filter whose rules can be reloaded while the server runs
+type Reloader interface {
+	Reload() error
+}
+
+// Result is the outcome of running a text through a Pipeline. Text has the
+// masked words replaced, Rejected and Flagged are set when any match asked
+// for it.
+type Result struct {
+	Text     string
+	Rejected bool
+	Flagged  bool
+	Matches  []Match
+}
+
+// Words returns the matched words with the given action
+func (r Result) Words(action Action) []string {
+	words := []string{}
+	for _, m := range r.Matches {
+		if m.Action == action {
+			words = append(words, m.Word)
+		}
+	}
+	return words
+}
+
+// Pipeline runs text through a list of filters
+type Pipeline struct {
+	filters []Filter
+}
+
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+// Moderate runs text through every filter and applies their actions
+func (p *Pipeline) Moderate(text string) Result {
+	result := Result{Text: text, Matches: []Match{}}
+
+	for _, f := range p.filters {
+		result.Matches = append(result.Matches, f.Match(text)...)
+	}
+
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].Start < result.Matches[j].Start
+	})
+
+	runes := []rune(text)
+	masked := strings.Builder{}
+	last := 0
+
+	for _, m := range result.Matches {
+		switch m.Action {
+		case ActionReject:
+			result.Rejected = true
+		case ActionFlag:
+			result.Flagged = true
+		case ActionMask:
+			// two filters may hit the same word, mask it once
+			if m.Start < last {
+				continue
+			}
+			masked.WriteString(string(runes[last:m.Start]))
+			masked.WriteString(_MASK)
+			last = m.End
+		}
+	}
+
+	masked.WriteString(string(runes[last:]))
+	result.Text = masked.String()
+
+	return result
+}
+
+// Reload reloads every filter in the pipeline that supports it
+func (p *Pipeline) Reload() error {
+	for _, f := range p.filters {
+		if r, ok := f.(Reloader); ok {
+			if err := r.Reload(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Token is a word in a text. Start and End are code point offsets, End
+// exclusive.
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// Tokenize splits text into words: runs of letters, digits and combining
+// marks in any script. Everything else, punctuation included, separates
+// words, so "Kerfuffle!" is the word "Kerfuffle".
+func Tokenize(text string) []Token {
+	tokens := []Token{}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if !isWordRune(runes[i]) {
+			continue
+		}
+
+		j := i + 1
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+
+		tokens = append(tokens, Token{Text: string(runes[i:j]), Start: i, End: j})
+		i = j
+	}
+
+	return tokens
+}
+
+// normalizeWord folds compatibility forms, such as fullwidth letters, and
+// case so rules match regardless of how a word is typed
+func normalizeWord(word string) string {
+	return strings.ToLower(norm.NFKC.String(word))
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
@@ -0,0 +1,170 @@
+package moderation
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestModerate(t *testing.T) {
+	pipeline := NewPipeline(NewWordList(map[string]Action{
+		"kerfuffle": ActionMask,
+		"sharbert":  ActionMask,
+		"fornax":    ActionReject,
+		"zoë":       ActionFlag,
+	}))
+
+	tests := []struct {
+		name         string
+		text         string
+		wantText     string
+		wantRejected bool
+		wantFlagged  bool
+	}{
+		{
+			name:     "Clean",
+			text:     "just a chirp",
+			wantText: "just a chirp",
+		},
+		{
+			name:     "Masked with punctuation",
+			text:     "what a Kerfuffle! sharbert, really",
+			wantText: "what a ****! ****, really",
+		},
+		{
+			name:     "Only whole words",
+			text:     "kerfuffles and unsharbert",
+			wantText: "kerfuffles and unsharbert",
+		},
+		{
+			name:     "Unicode offsets",
+			text:     "café «Kerfuffle»",
+			wantText: "café «****»",
+		},
+		{
+			name:     "Fullwidth",
+			text:     "what a ｋｅｒｆｕｆｆｌｅ",
+			wantText: "what a ****",
+		},
+		{
+			name:        "Decomposed",
+			text:        "hi Zoe\u0308",
+			wantText:    "hi Zoe\u0308",
+			wantFlagged: true,
+		},
+		{
+			name:         "Rejected",
+			text:         "hello FORNAX",
+			wantText:     "hello FORNAX",
+			wantRejected: true,
+		},
+		{
+			name:        "Flagged",
+			text:        "hi Zoë",
+			wantText:    "hi Zoë",
+			wantFlagged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pipeline.Moderate(tt.text)
+			if got.Text != tt.wantText || got.Rejected != tt.wantRejected || got.Flagged != tt.wantFlagged {
+				t.Errorf("Moderate(%q) = %q rejected=%v flagged=%v, want %q rejected=%v flagged=%v",
+					tt.text, got.Text, got.Rejected, got.Flagged, tt.wantText, tt.wantRejected, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Hi, naïve—world!")
+	want := []Token{
+		{Text: "Hi", Start: 0, End: 2},
+		{Text: "naïve", Start: 4, End: 9},
+		{Text: "world", Start: 10, End: 15},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize() = %v, want %v", got, want)
+	}
+}
+
+func TestParseWordList(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]Action
+		wantErr bool
+	}{
+		{
+			name:  "Actions and comments",
+			input: "# comment\n\nKerfuffle\nfornax reject\nzoë FLAG\n",
+			want:  map[string]Action{"kerfuffle": ActionMask, "fornax": ActionReject, "zoë": ActionFlag},
+		},
+		{
+			name:    "Unknown action",
+			input:   "fornax ban\n",
+			wantErr: true,
+		},
+		{
+			name:    "Not a single word",
+			input:   "for-nax\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWordList(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWordList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseWordList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordListReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("kerfuffle\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	wl, err := LoadWordList(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	pipeline := NewPipeline(wl)
+
+	if got := pipeline.Moderate("kerfuffle sharbert").Text; got != "**** sharbert" {
+		t.Errorf("before reload got %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte("sharbert\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := pipeline.Reload(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got := pipeline.Moderate("kerfuffle sharbert").Text; got != "kerfuffle ****" {
+		t.Errorf("after reload got %q", got)
+	}
+
+	// a broken file keeps the old rules
+	if err := os.WriteFile(path, []byte("sharbert ban\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := pipeline.Reload(); err == nil {
+		t.Errorf("expected an error reloading a broken word list")
+	}
+
+	if got := pipeline.Moderate("kerfuffle sharbert").Text; got != "kerfuffle ****" {
+		t.Errorf("after failed reload got %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// WordList is a Filter matching whole words against a list of rules, each
+// with its own action. A WordList loaded from a file can be reloaded while
+// it is in use.
+type WordList struct {
+	path  string
+	mu    sync.RWMutex
+	rules map[string]Action
+}
+
+// NewWordList creates a WordList from rules, a map of words to actions
+func NewWordList(rules map[string]Action) *WordList {
+	normalized := make(map[string]Action, len(rules))
+	for word, action := range rules {
+		normalized[normalizeWord(word)] = action
+	}
+	return &WordList{rules: normalized}
+}
+
+// LoadWordList creates a WordList from the file at path, see ParseWordList
+// for its format
+func LoadWordList(path string) (*WordList, error) {
+	wl := &WordList{path: path}
+	if err := wl.Reload(); err != nil {
+		return nil, err
+	}
+	return wl, nil
+}
+
+// Reload rereads the word list file. The rules in use are only replaced
+// once the whole file has parsed, so a bad edit keeps the old rules.
+func (wl *WordList) Reload() error {
+	if wl.path == "" {
+		return nil
+	}
+
+	f, err := os.Open(wl.path)
+	if err != nil {
+		return fmt.Errorf("error opening word list: %w", err)
+	}
+	defer f.Close()
+
+	rules, err := ParseWordList(f)
+	if err != nil {
+		return fmt.Errorf("error parsing word list %s: %w", wl.path, err)
+	}
+
+	wl.mu.Lock()
+	wl.rules = rules
+	wl.mu.Unlock()
+
+	return nil
+}
+
+// Match returns a match for every word of text that is in the list
+func (wl *WordList) Match(text string) []Match {
+	wl.mu.RLock()
+	defer wl.mu.RUnlock()
+
+	matches := []Match{}
+	for _, token := range Tokenize(text) {
+		action, ok := wl.rules[normalizeWord(token.Text)]
+		if !ok {
+			continue
+		}
+		matches = append(matches, Match{
+			Word:   token.Text,
+			Action: action,
+			Start:  token.Start,
+			End:    token.End,
+		})
+	}
+
+	return matches
+}
+
+// ParseWordList reads one rule per line as "<word> [mask|reject|flag]",
+// the action defaulting to mask. Blank lines and lines starting with # are
+// skipped.
+func ParseWordList(r io.Reader) (map[string]Action, error) {
+	rules := map[string]Action{}
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected a word and an optional action", lineNum)
+		}
+
+		if tokens := Tokenize(fields[0]); len(tokens) != 1 || tokens[0].Text != fields[0] {
+			return nil, fmt.Errorf("line %d: %q is not a single word", lineNum, fields[0])
+		}
+
+		action := ActionMask
+		if len(fields) == 2 {
+			var err error
+			if action, err = ParseAction(fields[1]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		}
+
+		rules[normalizeWord(fields[0])] = action
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,64 @@
+-- name: CreateBlock :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT (blocker_id, blocked_id) DO NOTHING;
+
+-- name: DeleteBlock :exec
+DELETE FROM blocks
+WHERE blocker_id = $1
+  AND blocked_id = $2;
+
+-- name: IsBlocked :one
+SELECT EXISTS (
+  SELECT 1
+  FROM blocks
+  WHERE blocker_id = sqlc.arg('blocker_id')
+    AND blocked_id = sqlc.arg('blocked_id')
+) AS blocked;
+
+-- name: GetBlockerIDs :many
+SELECT blocker_id
+FROM blocks
+WHERE blocked_id = sqlc.arg('blocked_id')
+  AND blocker_id = ANY(sqlc.arg('blocker_ids')::uuid[]);
+
+-- name: GetBlocked :many
+SELECT sqlc.embed(users), blocks.created_at AS blocked_at
+FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (blocks.created_at, users.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY blocks.created_at DESC, users.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT (muter_id, muted_id) DO NOTHING;
+
+-- name: DeleteMute :exec
+DELETE FROM mutes
+WHERE muter_id = $1
+  AND muted_id = $2;
+
+-- name: GetMuted :many
+SELECT sqlc.embed(users), mutes.created_at AS muted_at
+FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (mutes.created_at, users.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY mutes.created_at DESC, users.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: IsExcludedByViewer :one
+SELECT EXISTS (
+  SELECT 1
+  FROM blocks
+  WHERE blocker_id = sqlc.arg('viewer_id')
+    AND blocked_id = sqlc.arg('user_id')
+) OR EXISTS (
+  SELECT 1
+  FROM mutes
+  WHERE muter_id = sqlc.arg('viewer_id')
+    AND muted_id = sqlc.arg('user_id')
+) AS excluded;
@@ -0,0 +1,66 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, collection_id, created_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id, chirp_id) DO UPDATE
+SET collection_id = EXCLUDED.collection_id;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1
+  AND chirp_id = $2;
+
+-- name: GetBookmarks :many
+SELECT
+  sqlc.embed(chirps),
+  bookmarks.collection_id,
+  bookmarks.created_at AS bookmarked_at,
+  chirps.user_id IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('user_id')
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('user_id')
+  ) AS muted_author
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('collection_id')::uuid IS NULL OR bookmarks.collection_id = sqlc.narg('collection_id'))
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (bookmarks.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY bookmarks.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CreateBookmarkCollection :one
+INSERT INTO bookmark_collections (id, created_at, updated_at, user_id, name)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2)
+RETURNING *;
+
+-- name: GetBookmarkCollection :one
+SELECT *
+FROM bookmark_collections
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetBookmarkCollections :many
+SELECT sqlc.embed(bookmark_collections), COUNT(bookmarks.chirp_id) AS bookmark_count
+FROM bookmark_collections
+LEFT JOIN bookmarks ON bookmarks.collection_id = bookmark_collections.id
+WHERE bookmark_collections.user_id = $1
+GROUP BY bookmark_collections.id
+ORDER BY bookmark_collections.created_at ASC, bookmark_collections.id ASC;
+
+-- name: RenameBookmarkCollection :one
+UPDATE bookmark_collections
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  name = $3
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: DeleteBookmarkCollection :exec
+DELETE FROM bookmark_collections
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: CountCollectionBookmarks :one
+SELECT COUNT(*)
+FROM bookmarks
+WHERE collection_id = $1;
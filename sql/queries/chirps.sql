@@ -1,6 +1,6 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to_id, thread_id, quoted_chirp_id, is_rechirp)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetChirps :many
@@ -13,9 +13,111 @@ SELECT *
 FROM chirps
 WHERE chirps.id = $1;
 
+-- name: GetChirpForUpdate :one
+SELECT *
+FROM chirps
+WHERE chirps.id = $1
+FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  body = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: GetChirpsByIDs :many
+SELECT
+  sqlc.embed(chirps),
+  chirps.user_id IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('viewer_id')::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('viewer_id')::uuid
+  ) AS muted_author
+FROM chirps
+WHERE chirps.id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: HideChirp :exec
+UPDATE chirps
+SET hidden_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
 -- name: DeleteChirps :exec
 DELETE FROM chirps;
 
 -- name: DeleteChirp :exec
 DELETE FROM chirps
 WHERE chirps.id = $1;
+
+-- name: GetChirpsPageAsc :many
+SELECT *
+FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+  AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until'))
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND (sqlc.narg('exclude_id')::uuid IS NULL OR id <> sqlc.narg('exclude_id'))
+  AND (sqlc.arg('include_hidden')::boolean OR hidden_at IS NULL)
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('viewer_id')::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('viewer_id')::uuid
+  )
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsPageDesc :many
+SELECT *
+FROM chirps
+WHERE (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+  AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until'))
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND (sqlc.narg('exclude_id')::uuid IS NULL OR id <> sqlc.narg('exclude_id'))
+  AND (sqlc.arg('include_hidden')::boolean OR hidden_at IS NULL)
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('viewer_id')::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('viewer_id')::uuid
+  )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTimeline :many
+SELECT chirps.*
+FROM chirps
+WHERE (chirps.user_id = sqlc.arg('user_id')
+    OR chirps.user_id IN (
+      SELECT follows.followee_id
+      FROM follows
+      WHERE follows.follower_id = sqlc.arg('user_id')
+    ))
+  AND chirps.hidden_at IS NULL
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('user_id')
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('user_id')
+  )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetThread :many
+SELECT
+  sqlc.embed(chirps),
+  chirps.user_id IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('viewer_id')::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('viewer_id')::uuid
+  ) AS muted_author
+FROM chirps
+WHERE (chirps.id = sqlc.arg('thread_id') OR chirps.thread_id = sqlc.arg('thread_id'))
+ORDER BY chirps.created_at ASC, chirps.id ASC;
+
+-- name: GetReplyCounts :many
+SELECT in_reply_to_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE in_reply_to_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+  AND hidden_at IS NULL
+GROUP BY in_reply_to_id;
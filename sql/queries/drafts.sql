@@ -0,0 +1,69 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, in_reply_to_id, rechirp_of_id, quote_of_id, media_ids, poll_options, poll_closes_at, publish_at)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetDraft :one
+SELECT *
+FROM drafts
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetDrafts :many
+SELECT *
+FROM drafts
+WHERE user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  body = $3,
+  in_reply_to_id = $4,
+  rechirp_of_id = $5,
+  quote_of_id = $6,
+  media_ids = $7,
+  poll_options = $8,
+  poll_closes_at = $9,
+  publish_at = $10,
+  publish_error = '',
+  publish_attempts = 0
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetDueDraftForUpdate :one
+SELECT *
+FROM drafts
+WHERE publish_at <= CURRENT_TIMESTAMP
+ORDER BY publish_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: DeletePublishedDraft :exec
+DELETE FROM drafts
+WHERE id = $1;
+
+-- name: RetryDraft :exec
+UPDATE drafts
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  publish_at = CURRENT_TIMESTAMP + MAKE_INTERVAL(secs => sqlc.arg('backoff_seconds')::float8),
+  publish_attempts = publish_attempts + 1
+WHERE id = sqlc.arg('id');
+
+-- name: FailDraft :exec
+UPDATE drafts
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  publish_at = NULL,
+  publish_error = $2
+WHERE id = $1;
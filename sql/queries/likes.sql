@@ -0,0 +1,36 @@
+-- name: CreateChirpLike :exec
+INSERT INTO chirp_likes (chirp_id, user_id, created_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: DeleteChirpLike :exec
+DELETE FROM chirp_likes
+WHERE chirp_id = $1
+  AND user_id = $2;
+
+-- name: GetLikeCounts :many
+SELECT chirp_id, COUNT(*) AS like_count
+FROM chirp_likes
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirp_id;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id
+FROM chirp_likes
+WHERE user_id = sqlc.arg('user_id')
+  AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetLikedChirps :many
+SELECT sqlc.embed(chirps), chirp_likes.created_at AS liked_at
+FROM chirp_likes
+JOIN chirps ON chirps.id = chirp_likes.chirp_id
+WHERE chirp_likes.user_id = sqlc.arg('user_id')
+  AND chirps.hidden_at IS NULL
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (chirp_likes.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('viewer_id')::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('viewer_id')::uuid
+  )
+ORDER BY chirp_likes.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,43 @@
+-- name: CreateMediaAttachment :one
+INSERT INTO media_attachments (id, created_at, user_id, content_type, size_bytes, width, height, alt_text, storage_key, thumbnail_key, thumbnail_content_type)
+VALUES ($1, CURRENT_TIMESTAMP, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: AttachMedia :execrows
+UPDATE media_attachments
+SET chirp_id = $1,
+    position = $2
+WHERE id = $3
+  AND user_id = $4
+  AND chirp_id IS NULL;
+
+-- name: GetChirpMedia :many
+SELECT *
+FROM media_attachments
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: GetAllMedia :many
+SELECT *
+FROM media_attachments;
+
+-- name: DeleteUnattachedMedia :many
+WITH abandoned AS (
+  SELECT media_attachments.id
+  FROM media_attachments
+  WHERE media_attachments.chirp_id IS NULL
+    AND media_attachments.created_at < CURRENT_TIMESTAMP - MAKE_INTERVAL(secs => sqlc.arg('max_age_seconds')::float8)
+    AND NOT EXISTS (
+      SELECT 1
+      FROM drafts
+      WHERE media_attachments.id = ANY(drafts.media_ids)
+    )
+  ORDER BY media_attachments.created_at
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+)
+DELETE FROM media_attachments
+USING abandoned
+WHERE media_attachments.id = abandoned.id
+  AND media_attachments.chirp_id IS NULL
+RETURNING media_attachments.*;
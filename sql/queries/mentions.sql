@@ -0,0 +1,31 @@
+-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_offset, end_offset, created_at)
+VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP);
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
+
+-- name: GetChirpMentions :many
+SELECT *
+FROM chirp_mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, start_offset;
+
+-- name: GetMentioningChirps :many
+SELECT chirps.*
+FROM chirps
+WHERE chirps.id IN (
+    SELECT chirp_mentions.chirp_id
+    FROM chirp_mentions
+    WHERE chirp_mentions.user_id = sqlc.arg('user_id')
+  )
+  AND chirps.hidden_at IS NULL
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('user_id')
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('user_id')
+  )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,66 @@
+-- name: CreatePoll :one
+INSERT INTO polls (id, created_at, chirp_id, closes_at)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, $1, $2)
+RETURNING *;
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, poll_id, position, text)
+VALUES (GEN_RANDOM_UUID(), $1, $2, $3);
+
+-- name: GetPollByChirpID :one
+SELECT *
+FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetChirpPolls :many
+SELECT *
+FROM polls
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetPollOptions :many
+SELECT sqlc.embed(poll_options), COUNT(poll_votes.user_id) AS live_votes
+FROM poll_options
+LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+WHERE poll_options.poll_id = ANY(sqlc.arg('poll_ids')::uuid[])
+GROUP BY poll_options.id
+ORDER BY poll_options.poll_id, poll_options.position;
+
+-- name: GetPollVotes :many
+SELECT poll_id, option_id
+FROM poll_votes
+WHERE user_id = sqlc.arg('user_id')
+  AND poll_id = ANY(sqlc.arg('poll_ids')::uuid[]);
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (poll_id, user_id, option_id, created_at)
+SELECT polls.id, sqlc.arg('user_id')::uuid, poll_options.id, CURRENT_TIMESTAMP
+FROM polls
+JOIN poll_options ON poll_options.poll_id = polls.id
+WHERE polls.id = sqlc.arg('poll_id')
+  AND poll_options.id = sqlc.arg('option_id')
+  AND polls.closed_at IS NULL
+  AND polls.closes_at > CURRENT_TIMESTAMP
+FOR SHARE OF polls;
+
+-- name: ClosePolls :many
+WITH expired AS (
+  SELECT polls.id
+  FROM polls
+  WHERE polls.closed_at IS NULL
+    AND polls.closes_at <= CURRENT_TIMESTAMP
+  ORDER BY polls.closes_at
+  LIMIT sqlc.arg('limit')
+  FOR UPDATE SKIP LOCKED
+), tallied AS (
+  UPDATE poll_options
+  SET vote_count = (
+    SELECT COUNT(*)
+    FROM poll_votes
+    WHERE poll_votes.option_id = poll_options.id
+  )
+  WHERE poll_options.poll_id IN (SELECT id FROM expired)
+)
+UPDATE polls
+SET closed_at = CURRENT_TIMESTAMP
+WHERE polls.id IN (SELECT id FROM expired)
+RETURNING polls.id;
@@ -0,0 +1,63 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, reporter_id, reported_user_id, chirp_id, reason, details, status)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3, $4, $5, 'open')
+RETURNING *;
+
+-- name: UpsertFlaggedChirpReport :exec
+INSERT INTO reports (id, created_at, updated_at, reporter_id, reported_user_id, chirp_id, reason, details, status)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, NULL, sqlc.arg('reported_user_id'), sqlc.arg('chirp_id'), 'flagged', sqlc.arg('details'), 'open')
+ON CONFLICT (chirp_id) WHERE status <> 'resolved' AND reporter_id IS NULL DO UPDATE
+SET details = EXCLUDED.details,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: GetReport :one
+SELECT *
+FROM reports
+WHERE reports.id = $1;
+
+-- name: GetReportForUpdate :one
+SELECT *
+FROM reports
+WHERE reports.id = $1
+FOR UPDATE;
+
+-- name: GetReportsPage :many
+SELECT *
+FROM reports
+WHERE status = sqlc.arg('status')
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (created_at, id) > (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ClaimReport :one
+UPDATE reports
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  status = 'claimed',
+  claimed_by = $2,
+  claimed_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: ResolveReport :one
+UPDATE reports
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  status = 'resolved',
+  claimed_by = COALESCE(claimed_by, sqlc.arg('admin_id')::uuid),
+  claimed_at = COALESCE(claimed_at, CURRENT_TIMESTAMP),
+  resolution = sqlc.arg('resolution')::text,
+  resolved_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: CreateReportAction :one
+INSERT INTO report_actions (id, created_at, report_id, admin_id, action, note)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetReportActions :many
+SELECT *
+FROM report_actions
+WHERE report_id = $1
+ORDER BY created_at ASC, id ASC;
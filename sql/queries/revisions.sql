@@ -0,0 +1,10 @@
+-- name: CreateChirpRevision :one
+INSERT INTO chirp_revisions (id, chirp_id, body, authored_at, replaced_at)
+VALUES (GEN_RANDOM_UUID(), $1, $2, $3, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: GetChirpRevisions :many
+SELECT *
+FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY authored_at ASC, id ASC;
@@ -0,0 +1,15 @@
+-- name: GetUserRoles :many
+SELECT role
+FROM user_roles
+WHERE user_id = $1
+ORDER BY role ASC;
+
+-- name: GrantRole :exec
+INSERT INTO user_roles (user_id, role, granted_by, created_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+ON CONFLICT (user_id, role) DO NOTHING;
+
+-- name: RevokeRole :exec
+DELETE FROM user_roles
+WHERE user_id = $1
+  AND role = $2;
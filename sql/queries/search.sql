@@ -0,0 +1,21 @@
+-- name: SearchChirps :many
+SELECT
+  sqlc.embed(chirps),
+  TS_RANK_CD(chirps.search_vector, TO_TSQUERY('english', sqlc.arg('query')::text))::float8 AS rank,
+  TS_HEADLINE('english', TRANSLATE(chirps.body, CHR(57344) || CHR(57345), ''), TO_TSQUERY('english', sqlc.arg('query')::text), 'StartSel=' || CHR(57344) || ', StopSel=' || CHR(57345) || ', HighlightAll=true') AS highlight
+FROM chirps
+WHERE chirps.search_vector @@ TO_TSQUERY('english', sqlc.arg('query')::text)
+  AND chirps.hidden_at IS NULL
+  AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+  AND (
+    sqlc.narg('cursor_rank')::float8 IS NULL
+    OR (TS_RANK_CD(chirps.search_vector, TO_TSQUERY('english', sqlc.arg('query')::text))::float8, chirps.created_at, chirps.id)
+      < (sqlc.narg('cursor_rank'), sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('viewer_id')::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('viewer_id')::uuid
+  )
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,42 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, created_at, updated_at, user_id, user_agent, ip_address, device_label)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3, $4)
+RETURNING *;
+
+-- name: TouchSession :exec
+UPDATE sessions
+SET updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetActiveSessions :many
+SELECT sessions.*
+FROM sessions
+WHERE sessions.user_id = $1
+  AND EXISTS (
+    SELECT 1
+    FROM refresh_tokens
+    WHERE refresh_tokens.family_id = sessions.id
+      AND refresh_tokens.revoked_at IS NULL
+      AND CURRENT_TIMESTAMP < refresh_tokens.expires_at
+  )
+ORDER BY sessions.updated_at DESC, sessions.id DESC;
+
+-- name: IsSessionActive :one
+SELECT EXISTS (
+  SELECT 1
+  FROM refresh_tokens
+  WHERE family_id = sqlc.arg('session_id')
+    AND user_id = sqlc.arg('user_id')
+    AND revoked_at IS NULL
+    AND CURRENT_TIMESTAMP < expires_at
+) AS active;
+
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET
+  revoked_at = CURRENT_TIMESTAMP,
+  updated_at = CURRENT_TIMESTAMP
+WHERE family_id = $1
+  AND user_id = $2
+  AND revoked_at IS NULL
+  AND CURRENT_TIMESTAMP < expires_at;
@@ -0,0 +1,46 @@
+-- name: UpsertTag :one
+INSERT INTO tags (id, created_at, name)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, $1)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: GetTagByName :one
+SELECT *
+FROM tags
+WHERE tags.name = $1;
+
+-- name: CreateChirpTag :exec
+INSERT INTO chirp_tags (chirp_id, tag_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (chirp_id, tag_id) DO NOTHING;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1;
+
+-- name: GetChirpsByTag :many
+SELECT chirps.*
+FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+WHERE chirp_tags.tag_id = sqlc.arg('tag_id')
+  AND chirps.hidden_at IS NULL
+  AND (sqlc.narg('cursor_created_at')::timestamp IS NULL OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::uuid))
+  AND chirps.user_id NOT IN (
+    SELECT blocks.blocked_id FROM blocks WHERE blocks.blocker_id = sqlc.arg('viewer_id')::uuid
+    UNION ALL
+    SELECT mutes.muted_id FROM mutes WHERE mutes.muter_id = sqlc.arg('viewer_id')::uuid
+  )
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTrendingTags :many
+SELECT
+  tags.name,
+  SUM(POWER(0.5, EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - chirp_tags.created_at)) / sqlc.arg('half_life_seconds')::float8))::float8 AS score,
+  COUNT(*) AS uses
+FROM chirp_tags
+JOIN tags ON tags.id = chirp_tags.tag_id
+WHERE chirp_tags.created_at >= CURRENT_TIMESTAMP - sqlc.arg('window_seconds')::float8 * INTERVAL '1 second'
+GROUP BY tags.name
+ORDER BY score DESC, tags.name ASC
+LIMIT sqlc.arg('limit');
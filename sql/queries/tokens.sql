@@ -1,6 +1,6 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
-VALUES ($1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $2, CURRENT_TIMESTAMP + INTERVAL '60 day', NULL)
+INSERT INTO refresh_tokens (lookup_prefix, token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id)
+VALUES ($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $3, $5, NULL, $4)
 RETURNING *;
 
 -- name: GetRefreshTokens :many
@@ -10,24 +10,41 @@ WHERE CURRENT_TIMESTAMP < expires_at
   AND revoked_at IS NULL
 ORDER BY created_at ASC;
 
--- name: GetRefreshToken :one
+-- name: GetRefreshTokenForUpdate :one
 SELECT *
 FROM refresh_tokens
-WHERE token = $1
-  AND CURRENT_TIMESTAMP < expires_at
-  AND revoked_at IS NULL;
+WHERE lookup_prefix = $1
+  AND token_hash = $2
+FOR UPDATE;
 
 -- name: RevokeRefreshToken :one
 UPDATE refresh_tokens
 SET
   revoked_at = CURRENT_TIMESTAMP,
   updated_at = CURRENT_TIMESTAMP
-WHERE token = $1
+WHERE lookup_prefix = $1
+  AND token_hash = $2
 RETURNING revoked_at;
 
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET
+  revoked_at = CURRENT_TIMESTAMP,
+  updated_at = CURRENT_TIMESTAMP
+WHERE family_id = $1
+  AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET
+  revoked_at = CURRENT_TIMESTAMP,
+  updated_at = CURRENT_TIMESTAMP
+WHERE user_id = $1
+  AND revoked_at IS NULL;
+
 -- name: DeleteRefreshTokens :exec
 DELETE FROM refresh_tokens;
 
 -- name: DeleteRefreshToken :exec
 DELETE FROM refresh_tokens
-WHERE token = $1;
+WHERE token_hash = $1;
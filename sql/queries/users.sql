@@ -1,6 +1,6 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password, is_chirpy_red)
-VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, display_name, bio, avatar_url)
+VALUES (GEN_RANDOM_UUID(), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, $1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetUsers :many
@@ -18,12 +18,37 @@ SELECT *
 FROM users
 WHERE users.email = $1;
 
+-- name: GetUserByHandle :one
+SELECT *
+FROM users
+WHERE LOWER(users.handle) = LOWER(sqlc.arg('handle'));
+
+-- name: GetUsersByHandles :many
+SELECT *
+FROM users
+WHERE LOWER(users.handle) = ANY(sqlc.arg('handles')::text[]);
+
 -- name: UpdateUser :one
 UPDATE users
 SET 
   updated_at = CURRENT_TIMESTAMP,
-  email = $2,
-  hashed_password = $3
+  email = COALESCE(sqlc.narg('email'), email),
+  hashed_password = COALESCE(sqlc.narg('hashed_password'), hashed_password),
+  handle = COALESCE(sqlc.narg('handle'), handle),
+  display_name = COALESCE(sqlc.narg('display_name'), display_name),
+  bio = COALESCE(sqlc.narg('bio'), bio),
+  avatar_url = COALESCE(sqlc.narg('avatar_url'), avatar_url),
+  -- picking an avatar url replaces an uploaded avatar
+  avatar_key = CASE WHEN sqlc.narg('avatar_url')::text IS NULL THEN avatar_key ELSE '' END
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: SetUserAvatar :one
+UPDATE users
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  avatar_url = '',
+  avatar_key = $2
 WHERE id = $1
 RETURNING *;
 
@@ -35,9 +60,32 @@ SET
 WHERE id = $1
 RETURNING *;
 
+-- name: SuspendUser :exec
+UPDATE users
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  suspended_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
 -- name: DeleteUsers :exec
 DELETE FROM users;
 
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE users.id = $1;
+
+-- name: PinChirp :one
+UPDATE users
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  pinned_chirp_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: UnpinChirp :exec
+UPDATE users
+SET
+  updated_at = CURRENT_TIMESTAMP,
+  pinned_chirp_id = NULL
+WHERE id = $1
+  AND pinned_chirp_id = $2;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- in_reply_to_id and thread_id deliberately have no foreign key: when a
+-- parent is deleted its replies keep pointing at it so the thread can be
+-- rendered with a tombstone in its place. thread_id is the root chirp of
+-- the conversation and is NULL for chirps that start a thread.
+ALTER TABLE chirps
+ADD COLUMN in_reply_to_id UUID,
+ADD COLUMN thread_id UUID;
+
+CREATE INDEX chirps_thread_id_created_at_idx ON chirps (thread_id, created_at);
+CREATE INDEX chirps_in_reply_to_id_idx ON chirps (in_reply_to_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_in_reply_to_id_idx;
+DROP INDEX IF EXISTS chirps_thread_id_created_at_idx;
+
+ALTER TABLE chirps
+DROP COLUMN thread_id,
+DROP COLUMN in_reply_to_id;
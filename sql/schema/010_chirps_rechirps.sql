@@ -0,0 +1,17 @@
+-- +goose Up
+-- quoted_chirp_id has no foreign key for the same reason as in_reply_to_id:
+-- a deleted original is shown as a tombstone inside the rechirp or quote.
+ALTER TABLE chirps
+ADD COLUMN quoted_chirp_id UUID,
+ADD COLUMN is_rechirp BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX chirps_quoted_chirp_id_idx ON chirps (quoted_chirp_id);
+CREATE UNIQUE INDEX chirps_user_id_rechirp_idx ON chirps (user_id, quoted_chirp_id) WHERE is_rechirp;
+
+-- +goose Down
+DROP INDEX IF EXISTS chirps_user_id_rechirp_idx;
+DROP INDEX IF EXISTS chirps_quoted_chirp_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN is_rechirp,
+DROP COLUMN quoted_chirp_id;
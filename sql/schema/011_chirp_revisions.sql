@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chirp_revisions (
+    id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    authored_at TIMESTAMP NOT NULL,
+    replaced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX chirp_revisions_chirp_id_authored_at_idx ON chirp_revisions (chirp_id, authored_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_revisions;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE tags (
+    id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE chirp_tags (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chirp_id, tag_id)
+);
+
+CREATE INDEX chirp_tags_tag_id_idx ON chirp_tags (tag_id);
+-- trending only ever looks at recent rows, so it range scans this index
+CREATE INDEX chirp_tags_created_at_idx ON chirp_tags (created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS chirp_tags;
+DROP TABLE IF EXISTS tags;
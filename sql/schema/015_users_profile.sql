@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+
+-- users created before handles existed get a placeholder they can change
+UPDATE users
+SET handle = 'user_' || LEFT(REPLACE(id::text, '-', ''), 25)
+WHERE handle IS NULL;
+
+ALTER TABLE users
+ALTER COLUMN handle SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE users
+ALTER COLUMN handle DROP NOT NULL;
+
+ALTER TABLE users
+DROP COLUMN avatar_url,
+DROP COLUMN bio,
+DROP COLUMN display_name;
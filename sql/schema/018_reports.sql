@@ -0,0 +1,86 @@
+-- +goose Up
+-- admins review reports, there is no endpoint to make one yet:
+-- UPDATE users SET is_admin = true WHERE email = '...';
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN suspended_at TIMESTAMP;
+
+ALTER TABLE chirps
+ADD COLUMN hidden_at TIMESTAMP;
+
+-- a report is about a user, and about one of their chirps when chirp_id is
+-- set. reporter_id is NULL for chirps flagged by the moderation pipeline.
+-- chirp_id has no foreign key so reports outlive the chirp.
+CREATE TABLE reports (
+    id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reporter_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reported_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'claimed', 'resolved')),
+    claimed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    claimed_at TIMESTAMP,
+    resolution TEXT CHECK (resolution IN ('hide_chirp', 'suspend_user', 'dismiss')),
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX reports_status_created_at_idx ON reports (status, created_at, id);
+
+-- one open report per reporter and chirp, or per reporter and account
+CREATE UNIQUE INDEX reports_reporter_chirp_idx ON reports (reporter_id, chirp_id)
+WHERE status <> 'resolved' AND chirp_id IS NOT NULL;
+
+CREATE UNIQUE INDEX reports_reporter_user_idx ON reports (reporter_id, reported_user_id)
+WHERE status <> 'resolved' AND chirp_id IS NULL;
+
+-- and one open moderation pipeline report per chirp
+CREATE UNIQUE INDEX reports_flagged_chirp_idx ON reports (chirp_id)
+WHERE status <> 'resolved' AND reporter_id IS NULL;
+
+-- every claim and resolution, kept even if the acting admin is deleted
+CREATE TABLE report_actions (
+    id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    report_id UUID NOT NULL REFERENCES reports(id) ON DELETE CASCADE,
+    admin_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX report_actions_report_id_idx ON report_actions (report_id, created_at);
+
+-- chirps flagged by the moderation pipeline become reports
+INSERT INTO reports (created_at, updated_at, reported_user_id, chirp_id, reason, details)
+SELECT chirp_flags.created_at, chirp_flags.updated_at, chirps.user_id, chirps.id, 'flagged', ARRAY_TO_STRING(chirp_flags.words, ', ')
+FROM chirp_flags
+JOIN chirps ON chirps.id = chirp_flags.chirp_id;
+
+DROP TABLE chirp_flags;
+
+-- +goose Down
+CREATE TABLE chirp_flags (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    words TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO chirp_flags (chirp_id, words, created_at, updated_at)
+SELECT reports.chirp_id, STRING_TO_ARRAY(reports.details, ', '), reports.created_at, reports.updated_at
+FROM reports
+JOIN chirps ON chirps.id = reports.chirp_id
+WHERE reports.reporter_id IS NULL
+  AND reports.status <> 'resolved';
+
+DROP TABLE IF EXISTS report_actions;
+DROP TABLE IF EXISTS reports;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
+
+ALTER TABLE users
+DROP COLUMN suspended_at,
+DROP COLUMN is_admin;
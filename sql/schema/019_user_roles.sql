@@ -0,0 +1,32 @@
+-- +goose Up
+-- granted_by is NULL for roles granted outside the API, like the first
+-- admin: INSERT INTO user_roles (user_id, role) VALUES ('...', 'admin');
+CREATE TABLE user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'moderator')),
+    granted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO user_roles (user_id, role)
+SELECT id, 'admin'
+FROM users
+WHERE is_admin;
+
+ALTER TABLE users
+DROP COLUMN is_admin;
+
+-- +goose Down
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE users
+SET is_admin = true
+WHERE id IN (
+    SELECT user_id
+    FROM user_roles
+    WHERE role = 'admin'
+);
+
+DROP TABLE IF EXISTS user_roles;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- an upload belongs to its uploader until a chirp claims it, after which it
+-- lives and dies with that chirp. position orders a chirp's attachments.
+CREATE TABLE media_attachments (
+    id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    position INTEGER,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    alt_text TEXT NOT NULL DEFAULT '',
+    storage_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    thumbnail_content_type TEXT NOT NULL,
+    UNIQUE (chirp_id, position),
+    CHECK ((chirp_id IS NULL) = (position IS NULL))
+);
+
+CREATE INDEX media_attachments_user_id_idx ON media_attachments (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS media_attachments;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- avatar_key is the storage prefix of an uploaded avatar's resized
+-- variants, '' when the user has not uploaded one
+ALTER TABLE users
+ADD COLUMN avatar_key TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN avatar_key;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- a draft holds everything POST /api/chirps takes. the chirps it points at
+-- are not foreign keys, they are checked again when the draft is published.
+-- a draft with publish_at set is published by the scheduler once due and
+-- deleted in the same transaction. publish_error says why the last attempt
+-- failed, which also clears publish_at.
+CREATE TABLE drafts (
+    id UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL DEFAULT '',
+    in_reply_to_id UUID,
+    rechirp_of_id UUID,
+    quote_of_id UUID,
+    media_ids UUID[] NOT NULL DEFAULT '{}',
+    poll_options TEXT[] NOT NULL DEFAULT '{}',
+    poll_closes_at TIMESTAMP,
+    publish_at TIMESTAMP,
+    publish_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX drafts_user_id_created_at_idx ON drafts (user_id, created_at);
+CREATE INDEX drafts_publish_at_idx ON drafts (publish_at) WHERE publish_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS drafts;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- pinned_chirp_id is the chirp shown first on the user's profile. It is
+-- unpinned when that chirp is deleted.
+ALTER TABLE users
+ADD COLUMN pinned_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN pinned_chirp_id;
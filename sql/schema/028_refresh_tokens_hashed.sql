@@ -0,0 +1,31 @@
+-- +goose Up
+-- refresh tokens are kept as the hex SHA-256 digest of the token, found by
+-- lookup_prefix, its first 16 characters. existing tokens are converted in
+-- place so nobody is logged out.
+ALTER TABLE refresh_tokens
+ADD COLUMN lookup_prefix TEXT,
+ADD COLUMN token_hash TEXT;
+
+UPDATE refresh_tokens
+SET
+  lookup_prefix = LEFT(token, 16),
+  token_hash = ENCODE(SHA256(CONVERT_TO(token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens
+DROP COLUMN token,
+ALTER COLUMN lookup_prefix SET NOT NULL,
+ALTER COLUMN token_hash SET NOT NULL,
+ADD PRIMARY KEY (token_hash);
+
+CREATE UNIQUE INDEX refresh_tokens_lookup_prefix_idx ON refresh_tokens (lookup_prefix);
+
+-- +goose Down
+-- the plaintext tokens are gone, so going back logs everyone out
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS refresh_tokens_lookup_prefix_idx;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN lookup_prefix,
+DROP COLUMN token_hash,
+ADD COLUMN token TEXT PRIMARY KEY;
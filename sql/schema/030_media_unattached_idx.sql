@@ -0,0 +1,7 @@
+-- +goose Up
+-- uploads no chirp has claimed are deleted by the media cleanup once they
+-- are old enough, oldest first
+CREATE INDEX media_attachments_unattached_created_at_idx ON media_attachments (created_at) WHERE chirp_id IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS media_attachments_unattached_created_at_idx;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- publish_attempts counts the scheduler's failed tries to publish a draft.
+-- a draft that fails for a reason other than its own contents is retried
+-- with backoff until it runs out of attempts.
+ALTER TABLE drafts
+ADD COLUMN publish_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE drafts
+DROP COLUMN publish_attempts;
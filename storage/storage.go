@@ -0,0 +1,37 @@
+// Package storage holds the backends uploaded files are kept in
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Storage keeps files under slash separated keys such as "media/ab/cd.jpg"
+// and knows the public URL each one is served from
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// checkKey rejects keys that are empty or could escape the storage root
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid storage key %q", key)
+		}
+	}
+
+	return nil
+}
+
+// joinURL appends key to base with exactly one slash between them
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}